@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+//ChangeKind describes how the value stored at a Key changed.
+type ChangeKind int
+
+const (
+	//ChangeAdded means a value is stored at a Key that previously had none.
+	ChangeAdded ChangeKind = iota + 1
+
+	//ChangeRemoved means a value is no longer stored at a Key.
+	ChangeRemoved
+
+	//ChangeModified means the value stored at a Key was replaced by a different value.
+	ChangeModified
+)
+
+//String returns "added", "removed", "modified", or a numbered kind for unknown values.
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+//Change describes a change to a single Key value association.
+//Changes are always reported for set values, i.e. the Keys that are visited
+//by Values.EachKeyValue, and never for entire subtrees.
+type Change struct {
+	//Kind is how the value at Key changed.
+	Kind ChangeKind
+
+	//Key is the full Key of the changed value.
+	Key Key
+
+	//Old is the value previously stored at Key. It is nil for ChangeAdded.
+	Old interface{}
+
+	//New is the value now stored at Key. It is nil for ChangeRemoved.
+	New interface{}
+
+	//Sensitive is whether or not Key is marked as sensitive by the Values that
+	//reported c. See Values.MarkSensitive().
+	Sensitive bool
+}
+
+//String returns a human readable description of c such as
+//"modified [a b]: 1 -> 2".
+//Old and New are replaced by Redacted if c is Sensitive.
+func (c Change) String() string {
+	if c.Sensitive {
+		c.Old, c.New = Redacted, Redacted
+	}
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("%v %v: %v", c.Kind, c.Key, c.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("%v %v: %v", c.Kind, c.Key, c.Old)
+	}
+	return fmt.Sprintf("%v %v: %v -> %v", c.Kind, c.Key, c.Old, c.New)
+}
+
+//diffNodes calls visitor with every Change needed to turn old into new, both
+//of which are stored at key.
+//Either of old and new may be nil to signify nothing is stored at key.
+//Set values are compared with equal.
+//Changes are visited in Key order.
+func diffNodes(key Key, old, new *node, equal EqualFunc, visitor func(Change)) {
+	switch {
+	case old == nil && new == nil:
+		return
+
+	case old == nil:
+		new.eachSortedKeyValue(key, func(k Key, value interface{}) {
+			visitor(Change{Kind: ChangeAdded, Key: k, New: value})
+		})
+
+	case new == nil:
+		old.eachSortedKeyValue(key, func(k Key, value interface{}) {
+			visitor(Change{Kind: ChangeRemoved, Key: k, Old: value})
+		})
+
+	case old.isSet() && new.isSet():
+		if !equal(old.value, new.value) {
+			visitor(Change{Kind: ChangeModified, Key: key, Old: old.value, New: new.value})
+		}
+
+	case old.isSet() || new.isSet():
+		diffNodes(key, old, nil, equal, visitor)
+		diffNodes(key, nil, new, equal, visitor)
+
+	default:
+		for _, keyPart := range unionKeyParts(old.children, new.children) {
+			diffNodes(key.AppendStrings(keyPart), old.children[keyPart], new.children[keyPart], equal, visitor)
+		}
+	}
+}
+
+//collectChanges returns all Changes visited by diffNodes(key, old, new, equal).
+func collectChanges(key Key, old, new *node, equal EqualFunc) []Change {
+	var changes []Change
+	diffNodes(key, old, new, equal, func(change Change) {
+		changes = append(changes, change)
+	})
+	return changes
+}
+
+//unionKeyParts returns the sorted union of the keys in a and b.
+func unionKeyParts(a, b map[string]*node) []string {
+	result := make([]string, 0, len(a)+len(b))
+	for keyPart := range a {
+		result = append(result, keyPart)
+	}
+	for keyPart := range b {
+		if _, ok := a[keyPart]; !ok {
+			result = append(result, keyPart)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+//eachSortedKeyValue is n.eachKeyValue() with children visited in sorted order.
+func (n *node) eachSortedKeyValue(key Key, visitor func(key Key, value interface{})) {
+	if n.isSet() {
+		visitor(key, n.value)
+		return
+	}
+	for _, keyPart := range unionKeyParts(n.children, nil) {
+		n.children[keyPart].eachSortedKeyValue(key.AppendStrings(keyPart), visitor)
+	}
+}
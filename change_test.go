@@ -0,0 +1,126 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangeKind_String(t *testing.T) {
+	tests := []struct {
+		kind   ChangeKind
+		result string
+	}{
+		{ChangeAdded, "added"},
+		{ChangeRemoved, "removed"},
+		{ChangeModified, "modified"},
+		{ChangeKind(0), "ChangeKind(0)"},
+	}
+	for _, test := range tests {
+		if result := test.kind.String(); result != test.result {
+			t.Errorf("%d.String() = %v WANT %v", int(test.kind), result, test.result)
+		}
+	}
+}
+
+func TestChange_String(t *testing.T) {
+	tests := []struct {
+		change Change
+		result string
+	}{
+		{Change{Kind: ChangeAdded, Key: NewKey("a", "b"), New: 1}, "added [a b]: 1"},
+		{Change{Kind: ChangeRemoved, Key: NewKey("a"), Old: "old"}, "removed [a]: old"},
+		{Change{Kind: ChangeModified, Key: NewKey("a"), Old: 1, New: 2}, "modified [a]: 1 -> 2"},
+	}
+	for _, test := range tests {
+		if result := test.change.String(); result != test.result {
+			t.Errorf("change.String() = %v WANT %v", result, test.result)
+		}
+	}
+}
+
+func TestDiffNodes(t *testing.T) {
+	newTree := func(kvs ...KeyValue) *node {
+		v := NewValues()
+		for _, kv := range kvs {
+			v.Put(kv.Key, kv.Value)
+		}
+		return v.root
+	}
+	tests := []struct {
+		old     *node
+		new     *node
+		changes []Change
+	}{
+		{nil, nil, nil},
+		{newTree(), newTree(), nil},
+		{
+			nil,
+			newTree(NewKeyValue(NewKey("b"), 2), NewKeyValue(NewKey("a"), 1)),
+			[]Change{
+				{Kind: ChangeAdded, Key: NewKey("k", "a"), New: 1},
+				{Kind: ChangeAdded, Key: NewKey("k", "b"), New: 2},
+			},
+		},
+		{
+			newTree(NewKeyValue(NewKey("a"), 1)),
+			nil,
+			[]Change{
+				{Kind: ChangeRemoved, Key: NewKey("k", "a"), Old: 1},
+			},
+		},
+		{
+			newTree(NewKeyValue(nil, 1)),
+			newTree(NewKeyValue(nil, 2)),
+			[]Change{
+				{Kind: ChangeModified, Key: NewKey("k"), Old: 1, New: 2},
+			},
+		},
+		{
+			newTree(NewKeyValue(nil, 1)),
+			newTree(NewKeyValue(nil, 1)),
+			nil,
+		},
+		{
+			newTree(NewKeyValue(nil, 1)),
+			newTree(NewKeyValue(NewKey("a"), 2)),
+			[]Change{
+				{Kind: ChangeRemoved, Key: NewKey("k"), Old: 1},
+				{Kind: ChangeAdded, Key: NewKey("k", "a"), New: 2},
+			},
+		},
+		{
+			newTree(NewKeyValue(NewKey("a"), 2)),
+			newTree(NewKeyValue(nil, 1)),
+			[]Change{
+				{Kind: ChangeRemoved, Key: NewKey("k", "a"), Old: 2},
+				{Kind: ChangeAdded, Key: NewKey("k"), New: 1},
+			},
+		},
+		{
+			newTree(
+				NewKeyValue(NewKey("a"), "a"),
+				NewKeyValue(NewKey("b", "c"), "c"),
+				NewKeyValue(NewKey("d"), "d"),
+			),
+			newTree(
+				NewKeyValue(NewKey("a"), "a"),
+				NewKeyValue(NewKey("b", "c"), "new c"),
+				NewKeyValue(NewKey("e"), "e"),
+			),
+			[]Change{
+				{Kind: ChangeModified, Key: NewKey("k", "b", "c"), Old: "c", New: "new c"},
+				{Kind: ChangeRemoved, Key: NewKey("k", "d"), Old: "d"},
+				{Kind: ChangeAdded, Key: NewKey("k", "e"), New: "e"},
+			},
+		},
+	}
+	for index, test := range tests {
+		var changes []Change
+		diffNodes(NewKey("k"), test.old, test.new, DefaultEqual, func(change Change) {
+			changes = append(changes, change)
+		})
+		if !reflect.DeepEqual(changes, test.changes) {
+			t.Errorf("%v, diffNodes() = %v WANT %v", index, changes, test.changes)
+		}
+	}
+}
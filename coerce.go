@@ -0,0 +1,195 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	int64Type   = reflect.TypeOf(int64(0))
+	uint64Type  = reflect.TypeOf(uint64(0))
+	float64Type = reflect.TypeOf(float64(0))
+	boolType    = reflect.TypeOf(false)
+	stringType  = reflect.TypeOf("")
+)
+
+//ParseBool returns the boolean value represented by s.
+//It accepts everything strconv.ParseBool does along with the case insensitive
+//"yes", "y", "on", "no", "n", and "off".
+//Surrounding white space is ignored.
+func ParseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+	return strconv.ParseBool(strings.TrimSpace(s))
+}
+
+//coerceInt64 converts value into an int64.
+//Integer types are converted if they fit in an int64, floating point types if
+//they are integral and fit in an int64, and strings are parsed with
+//strconv.ParseInt in base 10, so that a leading zero does not mean octal.
+func coerceInt64(key Key, value interface{}) (int64, error) {
+	rv := reflect.ValueOf(value)
+	if i, ok := int64Value(rv); ok {
+		return i, nil
+	}
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), nil
+		}
+	case reflect.String:
+		i, err := strconv.ParseInt(strings.TrimSpace(rv.String()), 10, 64)
+		if err != nil {
+			return 0, newCoerceError(key, int64Type, value, err)
+		}
+		return i, nil
+	}
+	return 0, newCoerceError(key, int64Type, value, nil)
+}
+
+//coerceUint64 converts value into a uint64.
+//Integer types are converted if they are non negative, floating point types if
+//they are integral and fit in a uint64, and strings are parsed with
+//strconv.ParseUint in base 10.
+func coerceUint64(key Key, value interface{}) (uint64, error) {
+	rv := reflect.ValueOf(value)
+	if u, ok := uint64Value(rv); ok {
+		return u, nil
+	}
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f == math.Trunc(f) && f >= 0 && f < math.MaxUint64 {
+			return uint64(f), nil
+		}
+	case reflect.String:
+		u, err := strconv.ParseUint(strings.TrimSpace(rv.String()), 10, 64)
+		if err != nil {
+			return 0, newCoerceError(key, uint64Type, value, err)
+		}
+		return u, nil
+	}
+	return 0, newCoerceError(key, uint64Type, value, nil)
+}
+
+//coerceFloat64 converts value into a float64.
+//Integer and floating point types are converted and strings are parsed with
+//strconv.ParseFloat.
+func coerceFloat64(key Key, value interface{}) (float64, error) {
+	rv := reflect.ValueOf(value)
+	if f, ok := float64Value(rv); ok {
+		return f, nil
+	}
+	if rv.Kind() == reflect.String {
+		f, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		if err != nil {
+			return 0, newCoerceError(key, float64Type, value, err)
+		}
+		return f, nil
+	}
+	return 0, newCoerceError(key, float64Type, value, nil)
+}
+
+//coerceBool converts value into a bool.
+//Strings are parsed with ParseBool.
+func coerceBool(key Key, value interface{}) (bool, error) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		b, err := ParseBool(rv.String())
+		if err != nil {
+			return false, newCoerceError(key, boolType, value, err)
+		}
+		return b, nil
+	}
+	return false, newCoerceError(key, boolType, value, nil)
+}
+
+//coerceString converts value into a string.
+//Strings are returned as is, bools and numeric types are formatted with strconv,
+//and fmt.Stringers are formatted with their String method.
+//Subtrees, such as *Values, are never converted even though they are fmt.Stringers.
+func coerceString(key Key, value interface{}) (string, error) {
+	if _, ok := value.(*Values); ok {
+		return "", newCoerceError(key, stringType, value, nil)
+	}
+	if stringer, ok := value.(fmt.Stringer); ok {
+		return stringer.String(), nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64), nil
+	}
+	return "", newCoerceError(key, stringType, value, nil)
+}
+
+//coerceInto coerces value into out if out is a bool, integer, or floating point
+//type, and returns whether or not out is such a type along with any *TypeError
+//from the conversion.
+func coerceInto(key Key, value interface{}, out reflect.Value) (bool, error) {
+	var err error
+	switch out.Kind() {
+	case reflect.Bool:
+		var b bool
+		if b, err = coerceBool(key, value); err == nil {
+			out.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = coerceInt64(key, value); err == nil && out.OverflowInt(i) {
+			err = newCoerceError(key, out.Type(), value, nil)
+		} else if err == nil {
+			out.SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		if u, err = coerceUint64(key, value); err == nil && out.OverflowUint(u) {
+			err = newCoerceError(key, out.Type(), value, nil)
+		} else if err == nil {
+			out.SetUint(u)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = coerceFloat64(key, value); err == nil && out.OverflowFloat(f) {
+			err = newCoerceError(key, out.Type(), value, nil)
+		} else if err == nil {
+			out.SetFloat(f)
+		}
+	default:
+		return false, nil
+	}
+	if typeError, ok := err.(*TypeError); ok {
+		typeError.Want = out.Type()
+	}
+	return true, err
+}
+
+func newCoerceError(key Key, want reflect.Type, value interface{}, err error) *TypeError {
+	return &TypeError{
+		Key:  key,
+		Want: want,
+		Got:  reflect.TypeOf(value),
+		Err:  err,
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseBool(t *testing.T) {
+	tests := []struct {
+		s      string
+		result bool
+		err    bool
+	}{
+		{"true", true, false},
+		{"FALSE", false, false},
+		{"1", true, false},
+		{"0", false, false},
+		{"yes", true, false},
+		{" Yes ", true, false},
+		{"y", true, false},
+		{"on", true, false},
+		{"ON", true, false},
+		{"no", false, false},
+		{"n", false, false},
+		{"off", false, false},
+		{"", false, true},
+		{"maybe", false, true},
+	}
+	for _, test := range tests {
+		result, err := ParseBool(test.s)
+		if result != test.result || (err != nil) != test.err {
+			t.Errorf("ParseBool(%q) = %v, %v WANT %v, error %v", test.s, result, err, test.result, test.err)
+		}
+	}
+}
+
+func TestCoerceInt64(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		result int64
+		err    bool
+	}{
+		{int8(-8), -8, false},
+		{uint32(32), 32, false},
+		{int64(math.MaxInt64), math.MaxInt64, false},
+		{uint64(math.MaxUint64), 0, true},
+		{float64(12), 12, false},
+		{float32(1.5), 0, true},
+		{"42", 42, false},
+		{" -42 ", -42, false},
+		{"010", 10, false},
+		{"0x10", 0, true},
+		{"1_000", 0, true},
+		{"forty two", 0, true},
+		{true, 0, true},
+		{nil, 0, true},
+	}
+	for _, test := range tests {
+		result, err := coerceInt64(NewKey("key"), test.value)
+		if result != test.result || (err != nil) != test.err {
+			t.Errorf("coerceInt64(%#v) = %v, %v WANT %v, error %v", test.value, result, err, test.result, test.err)
+		}
+	}
+}
+
+func TestCoerceUint64(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		result uint64
+		err    bool
+	}{
+		{int8(8), 8, false},
+		{int8(-8), 0, true},
+		{uint64(math.MaxUint64), math.MaxUint64, false},
+		{float64(12), 12, false},
+		{float64(-1), 0, true},
+		{"42", 42, false},
+		{"010", 10, false},
+		{"-42", 0, true},
+		{"0x10", 0, true},
+		{true, 0, true},
+		{nil, 0, true},
+	}
+	for _, test := range tests {
+		result, err := coerceUint64(NewKey("key"), test.value)
+		if result != test.result || (err != nil) != test.err {
+			t.Errorf("coerceUint64(%#v) = %v, %v WANT %v, error %v", test.value, result, err, test.result, test.err)
+		}
+	}
+}
+
+func TestCoerceFloat64(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		result float64
+		err    bool
+	}{
+		{float32(1.5), 1.5, false},
+		{1.25, 1.25, false},
+		{int16(3), 3, false},
+		{uint(4), 4, false},
+		{"2.5", 2.5, false},
+		{"1e3", 1000, false},
+		{"two", 0, true},
+		{false, 0, true},
+	}
+	for _, test := range tests {
+		result, err := coerceFloat64(NewKey("key"), test.value)
+		if result != test.result || (err != nil) != test.err {
+			t.Errorf("coerceFloat64(%#v) = %v, %v WANT %v, error %v", test.value, result, err, test.result, test.err)
+		}
+	}
+}
+
+func TestCoerceBool(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		result bool
+		err    bool
+	}{
+		{true, true, false},
+		{false, false, false},
+		{"on", true, false},
+		{"off", false, false},
+		{"nope", false, true},
+		{1, false, true},
+	}
+	for _, test := range tests {
+		result, err := coerceBool(NewKey("key"), test.value)
+		if result != test.result || (err != nil) != test.err {
+			t.Errorf("coerceBool(%#v) = %v, %v WANT %v, error %v", test.value, result, err, test.result, test.err)
+		}
+	}
+}
+
+func TestCoerceString(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		result string
+		err    bool
+	}{
+		{"string", "string", false},
+		{true, "true", false},
+		{-12, "-12", false},
+		{uint8(12), "12", false},
+		{float32(1.5), "1.5", false},
+		{0.1, "0.1", false},
+		{time.Second, "1s", false},
+		{[]string{}, "", true},
+		{NewValues(), "", true},
+		{nil, "", true},
+	}
+	for _, test := range tests {
+		result, err := coerceString(NewKey("key"), test.value)
+		if result != test.result || (err != nil) != test.err {
+			t.Errorf("coerceString(%#v) = %v, %v WANT %v, error %v", test.value, result, err, test.result, test.err)
+		}
+	}
+}
+
+func TestCoerce_parseErrorIsWrapped(t *testing.T) {
+	_, err := coerceInt64(NewKey("a", "b"), "abc")
+	typeErr, ok := err.(*TypeError)
+	if !ok {
+		t.Fatalf("err = %T WANT *TypeError", err)
+	}
+	if !typeErr.Key.Equal(NewKey("a", "b")) || typeErr.Want != int64Type || typeErr.Got != stringType {
+		t.Errorf("typeErr = %+v", typeErr)
+	}
+	if _, ok := typeErr.Unwrap().(*strconv.NumError); !ok {
+		t.Errorf("typeErr.Unwrap() = %T WANT *strconv.NumError", typeErr.Unwrap())
+	}
+}
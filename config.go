@@ -1,6 +1,9 @@
 package config
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 //Config provides methods to store, retrieve, and remove arbitrary values
 //that are referenced by keys.
@@ -23,10 +26,45 @@ type Config struct {
 	//to a Key type.
 	KeyParser KeyParser
 
+	//Interpolator, if not nil, resolves references within the string values
+	//loaded by MergeLoaders(), LoadAll(), Reload(), and LoadLayer().
+	//Its KeyParser defaults to this Config's KeyParser.
+	//See Interpolator for the reference syntax.
+	Interpolator *Interpolator
+
+	//ConcurrentLoading, if true, makes MergeLoaders(), LoadAll(), Reload(), and
+	//LoadLayer() call all of their Loaders concurrently, so that loading takes
+	//as long as the slowest Loader instead of the sum of all of them.
+	//The results are still merged in the order the Loaders are given, and so
+	//precedence is the same as when loading one Loader after another.
+	//Loaders must then be safe to load concurrently with each other.
+	ConcurrentLoading bool
+
+	//BestEffort, if true, makes MergeLoaders(), LoadAll(), Reload(), and
+	//LoadLayer() load every Loader even if some fail, and use the merged values
+	//of the Loaders that succeed. A *LoadErrors describing every failure is then
+	//returned along with the result.
+	//LoadAll() and Reload() use the values last loaded by a failing Loader added
+	//with AddLoaders(), if any, in place of its new values, and so a Loader that
+	//fails does not remove its keys.
+	//Otherwise, the first failure is returned and c is unchanged.
+	//Either way, failures that a Loader's LoadPolicy ignores are not failures.
+	//See WithPolicy().
+	BestEffort bool
+
 	values *Values
 
 	lock    *sync.Mutex
 	loaders []Loader
+	//loaded holds the values last loaded by each of loaders, or nil for those
+	//that have not been loaded. It is guarded by lock.
+	loaded []*Values
+
+	watchLock *sync.RWMutex
+	watchers  []*watcher
+
+	layerLock *sync.Mutex
+	layers    []*layer
 }
 
 //New creates a new *Config with an empty Values and Loaders.
@@ -39,6 +77,10 @@ func New() *Config {
 
 		lock:    &sync.Mutex{},
 		loaders: []Loader{},
+
+		watchLock: &sync.RWMutex{},
+
+		layerLock: &sync.Mutex{},
 	}
 }
 
@@ -57,10 +99,19 @@ func (c *Config) AddLoaders(loaders ...Loader) *Config {
 //LoadAll is a helper for c.MergeLoaders() called with all Loaders
 //added previously with c.AddLoaders().
 func (c *Config) LoadAll() (bool, error) {
+	return c.LoadAllContext(context.Background())
+}
+
+//LoadAllContext is c.LoadAll() that honors ctx as c.MergeLoadersContext() does.
+func (c *Config) LoadAllContext(ctx context.Context) (bool, error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	return c.MergeLoaders(c.loaders...)
+	changed, loaded, err := c.mergeLoadersContext(ctx, c.loaders, c.loaded)
+	if loaded != nil {
+		c.loaded = loaded
+	}
+	return changed, err
 }
 
 //Values returns the internal Values used for storage.
@@ -71,24 +122,63 @@ func (c *Config) Values() *Values {
 	return c.values
 }
 
+//MarkSensitive is sugar for c.Values().MarkSensitive() with each pattern parsed
+//by c.KeyParser.
+//See Values.MarkSensitive() for the pattern syntax.
+func (c *Config) MarkSensitive(patterns ...string) {
+	keys := make([]Key, 0, len(patterns))
+	for _, pattern := range patterns {
+		keys = append(keys, c.NewKey(pattern))
+	}
+	c.values.MarkSensitive(keys...)
+}
+
+//IsSensitive is sugar for c.Values().IsSensitive(c.NewKey(key)).
+func (c *Config) IsSensitive(key string) bool {
+	return c.values.IsSensitive(c.NewKey(key))
+}
+
+//String is sugar for c.Values().String(), and so sensitive values are redacted.
+func (c *Config) String() string {
+	return c.values.String()
+}
+
 //EqualValues is sugar for c.Values().Equal(other.Values()).
 func (c *Config) EqualValues(other *Config) bool {
 	return c.values.Equal(other.values)
 }
 
+//DiffValues is sugar for c.Values().Diff(other.Values()).
+func (c *Config) DiffValues(other *Config) []Change {
+	return c.values.Diff(other.values)
+}
+
 //Clone creates and returns a new *Config with KeyParser and added loaders
 //shallow copied, and with *Values cloned via *Values.Clone().
+//Layers are copied so that the result's layers are independent of c's.
+//Watchers registered with c are not registered with the result.
 func (c *Config) Clone() *Config {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	c.layerLock.Lock()
+	defer c.layerLock.Unlock()
 
 	return &Config{
-		KeyParser: c.KeyParser,
+		KeyParser:         c.KeyParser,
+		Interpolator:      c.Interpolator,
+		ConcurrentLoading: c.ConcurrentLoading,
+		BestEffort:        c.BestEffort,
 
 		values: c.values.Clone(),
 
 		lock:    &sync.Mutex{},
 		loaders: c.loaders,
+		loaded:  c.loaded,
+
+		watchLock: &sync.RWMutex{},
+
+		layerLock: &sync.Mutex{},
+		layers:    c.cloneLayers(),
 	}
 }
 
@@ -133,6 +223,20 @@ func (c *Config) GetInt64Ok(key string) (i int64, ok bool) {
 	return
 }
 
+//GetInt64As returns the value stored at key coerced into an int64.
+//Integer types are converted if they fit in an int64, integral floating point
+//types are truncated, and strings are parsed with strconv.ParseInt in base 10.
+//A *KeyNotFoundError is returned if no value exists at key, and a *TypeError
+//(possibly wrapping the parse error) is returned if the value cannot be coerced.
+func (c *Config) GetInt64As(key string) (i int64, err error) {
+	k := c.NewKey(key)
+	v, ok := c.GetKeyOk(k)
+	if !ok {
+		return 0, &KeyNotFoundError{Key: k}
+	}
+	return coerceInt64(k, v)
+}
+
 //GetBool returns a bool stored at key.
 //The zero value for bool is returned if a bool does not exist at key.
 func (c *Config) GetBool(key string) (b bool) {
@@ -152,6 +256,19 @@ func (c *Config) GetBoolOk(key string) (b bool, ok bool) {
 	return
 }
 
+//GetBoolAs returns the value stored at key coerced into a bool.
+//Strings are parsed with ParseBool and so accept values such as "yes" and "off".
+//A *KeyNotFoundError is returned if no value exists at key, and a *TypeError
+//(possibly wrapping the parse error) is returned if the value cannot be coerced.
+func (c *Config) GetBoolAs(key string) (b bool, err error) {
+	k := c.NewKey(key)
+	v, ok := c.GetKeyOk(k)
+	if !ok {
+		return false, &KeyNotFoundError{Key: k}
+	}
+	return coerceBool(k, v)
+}
+
 //GetString returns a string stored at key.
 //The zero value for string is returned if a string does not exist at key.
 func (c *Config) GetString(key string) (s string) {
@@ -171,6 +288,20 @@ func (c *Config) GetStringOk(key string) (s string, ok bool) {
 	return
 }
 
+//GetStringAs returns the value stored at key coerced into a string.
+//Bools and numeric types are formatted with strconv and fmt.Stringers with
+//their String method.
+//A *KeyNotFoundError is returned if no value exists at key, and a *TypeError
+//is returned if the value cannot be coerced.
+func (c *Config) GetStringAs(key string) (s string, err error) {
+	k := c.NewKey(key)
+	v, ok := c.GetKeyOk(k)
+	if !ok {
+		return "", &KeyNotFoundError{Key: k}
+	}
+	return coerceString(k, v)
+}
+
 //GetFloat64 returns a float64 casted floating point type stored at key.
 //The zero value for float64 is returned if a floating point type does not exist at key.
 func (c *Config) GetFloat64(key string) (f float64) {
@@ -196,6 +327,20 @@ func (c *Config) GetFloat64Ok(key string) (f float64, ok bool) {
 	return
 }
 
+//GetFloat64As returns the value stored at key coerced into a float64.
+//Integer and floating point types are converted and strings are parsed with
+//strconv.ParseFloat.
+//A *KeyNotFoundError is returned if no value exists at key, and a *TypeError
+//(possibly wrapping the parse error) is returned if the value cannot be coerced.
+func (c *Config) GetFloat64As(key string) (f float64, err error) {
+	k := c.NewKey(key)
+	v, ok := c.GetKeyOk(k)
+	if !ok {
+		return 0, &KeyNotFoundError{Key: k}
+	}
+	return coerceFloat64(k, v)
+}
+
 //GetValues returns a *Values stored at key.
 //This means that there exists some value stored at a longer Key.
 //The returned *Values is cloned and thus changes to v DO NOT AFFECT c and vice versa.
@@ -219,6 +364,41 @@ func (c *Config) GetValuesOk(key string) (v *Values, ok bool) {
 	return
 }
 
+//GetSlice returns the elements of the list stored at key.
+//See GetSliceOk.
+func (c *Config) GetSlice(key string) (s []interface{}) {
+	s, _ = c.GetSliceOk(key)
+	return
+}
+
+//GetSliceOk is sugar for c.Values().GetSliceOk(c.NewKey(key)).
+//Elements that are subtrees are cloned *Values, and thus changes to them
+//DO NOT AFFECT c.
+//The return value ok indicates whether or not a list is stored at key.
+func (c *Config) GetSliceOk(key string) (s []interface{}, ok bool) {
+	return c.values.GetSliceOk(c.NewKey(key))
+}
+
+//GetValuesSlice returns the elements of the list stored at key as *Values.
+//See GetValuesSliceOk.
+func (c *Config) GetValuesSlice(key string) (s []*Values) {
+	s, _ = c.GetValuesSliceOk(key)
+	return
+}
+
+//GetValuesSliceOk is sugar for c.Values().GetValuesSliceOk(c.NewKey(key)).
+//Each *Values is cloned and thus changes to them DO NOT AFFECT c.
+//The return value ok indicates whether or not a list with only subtree elements
+//is stored at key.
+func (c *Config) GetValuesSliceOk(key string) (s []*Values, ok bool) {
+	return c.values.GetValuesSliceOk(c.NewKey(key))
+}
+
+//Len is sugar for c.Values().Len(c.NewKey(key)).
+func (c *Config) Len(key string) int {
+	return c.values.Len(c.NewKey(key))
+}
+
 //Get is sugar for c.GetKey(c.NewKey(key)).
 //It returns a raw interface{} value stored at key or nil if a value does not
 //exist at key.
@@ -249,9 +429,42 @@ func (c *Config) GetKeyOk(key Key) (v interface{}, ok bool) {
 	return c.values.GetOk(key)
 }
 
-//Merge is sugar for c.Values().Merge(Key(nil), other.Values()).
+//Source is sugar for c.SourceKey(c.NewKey(key)).
+func (c *Config) Source(key string) (source string, ok bool) {
+	return c.SourceKey(c.NewKey(key))
+}
+
+//SourceKey returns a description of where the value stored at key came from,
+//e.g. "env APP_DB_HOST" or "file /etc/app.json".
+//Values merged from Loaders by c.MergeLoaders(), c.LoadAll(), and c.Reload()
+//have the source recorded by the Loader or, if none was, the Loader's name
+//(see Named).
+//ok is false for subtrees, missing values, and values without a known source,
+//such as those set with c.Put().
+func (c *Config) SourceKey(key Key) (source string, ok bool) {
+	return c.values.Source(key)
+}
+
+//Unmarshal is sugar for c.UnmarshalKey(c.NewKey(key), out).
+//Use UnmarshalKey with an empty Key to decode all of c.
+func (c *Config) Unmarshal(key string, out interface{}) error {
+	return c.UnmarshalKey(c.NewKey(key), out)
+}
+
+//UnmarshalKey decodes the values stored at key into the value pointed to by out.
+//It is sugar for c.Values().DecodeKey(key, out).
+//See *Values.DecodeKey for the decoding rules.
+func (c *Config) UnmarshalKey(key Key, out interface{}) error {
+	return c.values.DecodeKey(key, out)
+}
+
+//Merge is sugar for c.Values().Merge(Key(nil), other.Values()) that also notifies
+//watchers of the resulting Changes.
 func (c *Config) Merge(other *Config) (changed bool) {
-	return c.values.Merge(nil, other.values)
+	snapshot := other.values.Snapshot()
+	return c.update(nil, func(v *Values) bool {
+		return v.merge(nil, snapshot)
+	})
 }
 
 //Put is sugar for c.PutKey(c.NewKey(key), value).
@@ -259,37 +472,303 @@ func (c *Config) Put(key string, value interface{}) (changed bool) {
 	return c.PutKey(c.NewKey(key), value)
 }
 
-//PutKey is sugar for c.Values().Put(key, value).
+//PutKey is sugar for c.Values().Put(key, value) that also notifies watchers
+//of the resulting Changes.
 func (c *Config) PutKey(key Key, value interface{}) (changed bool) {
-	return c.values.Put(key, value)
+	value = snapshotValue(value)
+	return c.update(key, func(v *Values) bool {
+		return v.put(key, value)
+	})
 }
 
 //MergeLoaders creates a temporary Values into which all Loaders in loaders are merged.
 //If an error occurs on any individual Loader.Load(), then MergeLoaders returns
-//immediately with that error and does not change c in any way.
+//immediately with that error, as a *LoadError, and does not change c in any way, unless
+//c.BestEffort is true or the Loader's LoadPolicy ignores the error.
 //If all Loader.Load() do not error, then the temporary Values are merged into
-//c's Values.
+//c's Values and watchers are notified of the resulting Changes.
+//
+//If c.Interpolator is not nil, then the loaded values are interpolated before
+//being merged, with references resolved against c's values merged with the
+//loaded values. An *InterpolationError is returned, and c is unchanged, if any
+//reference cannot be resolved.
 func (c *Config) MergeLoaders(loaders ...Loader) (changed bool, err error) {
-	temp := NewValues()
-	for _, loader := range loaders {
-		loaderValues, err := loader.Load()
+	return c.MergeLoadersContext(context.Background(), loaders...)
+}
+
+//MergeLoadersContext is c.MergeLoaders() that stops loading once ctx is done.
+//Loaders that implement ContextLoader are loaded with LoadContext(ctx), and
+//Load() is used for all others. If ctx is done before all loaders return, then
+//ctx.Err() is returned and c is unchanged.
+func (c *Config) MergeLoadersContext(ctx context.Context, loaders ...Loader) (changed bool, err error) {
+	changed, _, err = c.mergeLoadersContext(ctx, loaders, nil)
+	return changed, err
+}
+
+//mergeLoadersContext is c.MergeLoadersContext() that loads loaders as
+//c.mergeLoaders() does with previous, and that also returns the values loaded
+//by each of loaders, or nil if c is unchanged.
+func (c *Config) mergeLoadersContext(ctx context.Context, loaders []Loader, previous []*Values) (changed bool, loaded []*Values, err error) {
+	temp, loaded, changed, loadErr := c.mergeLoaders(ctx, loaders, previous)
+	if temp == nil {
+		return false, nil, loadErr
+	}
+	c.update(nil, func(v *Values) bool {
+		if err = c.interpolateInto(v, temp); err != nil {
+			return false
+		}
+		return v.merge(nil, temp)
+	})
+	if err != nil {
+		return false, nil, err
+	}
+	return changed, loaded, loadErr
+}
+
+//Reload creates a new Values from all Loaders added with c.AddLoaders() and
+//replaces all of c's values with it.
+//Unlike c.LoadAll(), values that are no longer provided by any Loader are
+//removed from c.
+//
+//If an error occurs on any individual Loader.Load(), then Reload returns that
+//error and does not change c in any way, unless c.BestEffort is true or the
+//Loader's LoadPolicy ignores the error.
+//With c.BestEffort, a Loader that fails keeps providing the values it last
+//loaded, if any, and so its keys are not removed from c.
+//If c.Interpolator is not nil, then the loaded values are interpolated with
+//references resolved against the loaded values alone.
+//Otherwise the swap happens atomically with respect to other uses of c's Values,
+//watchers are notified of the resulting Changes, and those Changes are returned.
+func (c *Config) Reload() ([]Change, error) {
+	return c.ReloadContext(context.Background())
+}
+
+//ReloadContext is c.Reload() that honors ctx as c.MergeLoadersContext() does.
+func (c *Config) ReloadContext(ctx context.Context) ([]Change, error) {
+	changes, err := c.reload(ctx)
+	c.notify(changes)
+	return changes, err
+}
+
+//reload does the work of c.ReloadContext() without notifying watchers.
+//c.lock is held so that the values loaded by each Loader are kept consistent.
+func (c *Config) reload(ctx context.Context) ([]Change, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	temp, loaded, _, loadErr := c.mergeLoaders(ctx, c.loaders, c.loaded)
+	if temp == nil {
+		return nil, loadErr
+	}
+	if c.Interpolator != nil {
+		if err := c.Interpolator.interpolateInto(c.KeyParser, newNode(), temp); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := c.replaceOwn(temp)
+	c.loaded = loaded
+	return changes, loadErr
+}
+
+//mergeLoaders returns a new Values into which the results of all loaders are
+//merged in order.
+//Values that a loader does not record a source for are given the loader's name
+//as their source.
+//changed indicates whether or not any merge changed the result.
+//Loaders are loaded one after another, or concurrently if c.ConcurrentLoading
+//is true, in which case the remaining loaders are canceled once the result is
+//decided.
+//
+//loaded holds the values loaded by each of loaders, or nil for those whose
+//errors were ignored.
+//
+//Errors that a loader's LoadPolicy ignores are skipped. If c.BestEffort is false,
+//then the first other error, in order, is returned immediately, as a *LoadError,
+//along with a nil temp. Otherwise all loaders are loaded, and a *LoadErrors holding every other
+//error is returned along with the merged results of the loaders that succeeded.
+//A loader that fails then has previous at its index, if not nil, merged and
+//kept in loaded in place of its own values.
+func (c *Config) mergeLoaders(ctx context.Context, loaders []Loader, previous []*Values) (temp *Values, loaded []*Values, changed bool, err error) {
+	load := func(i int) (*Values, error) {
+		return LoadContext(ctx, loaders[i])
+	}
+	if c.ConcurrentLoading {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		load = loadConcurrently(ctx, loaders)
+	}
+
+	temp = NewValues()
+	loaded = make([]*Values, len(loaders))
+	loadErrors := &LoadErrors{}
+	for i, loader := range loaders {
+		loaderValues, err := load(i)
 		if err != nil {
-			return false, err
+			if LoaderPolicy(loader).Ignores(err) {
+				continue
+			}
+			if !c.BestEffort {
+				return nil, nil, false, newLoadError(loader, err)
+			}
+			loadErrors.Errors = append(loadErrors.Errors, newLoadError(loader, err))
+			if i >= len(previous) || previous[i] == nil {
+				continue
+			}
+			loaderValues = previous[i]
 		}
-		changed = temp.Merge(nil, loaderValues) || changed
+		loaded[i] = loaderValues.Snapshot()
+		changed = temp.mergeSource(nil, loaded[i], LoaderName(loader)) || changed
+	}
+	if len(loadErrors.Errors) > 0 {
+		return temp, loaded, changed, loadErrors
+	}
+	return temp, loaded, changed, nil
+}
+
+//newLoadError returns err if it is a *LoadError, and a *LoadError for loader
+//wrapping err otherwise.
+func newLoadError(loader Loader, err error) *LoadError {
+	if loadError, ok := err.(*LoadError); ok {
+		return loadError
+	}
+	return &LoadError{Loader: loader, Err: err}
+}
+
+//loadConcurrently starts loading all loaders concurrently and returns a func
+//that waits for and returns the result of the loader at index i.
+func loadConcurrently(ctx context.Context, loaders []Loader) func(i int) (*Values, error) {
+	type result struct {
+		values *Values
+		err    error
+	}
+	results := make([]chan result, len(loaders))
+	for i, loader := range loaders {
+		results[i] = make(chan result, 1)
+		go func(loader Loader, results chan<- result) {
+			values, err := LoadContext(ctx, loader)
+			results <- result{values, err}
+		}(loader, results[i])
+	}
+	return func(i int) (*Values, error) {
+		r := <-results[i]
+		return r.values, r.err
 	}
-	c.values.Merge(nil, temp)
-	return changed, nil
 }
 
-//Remove removes a Key value association in c's Values.
+//Interpolate resolves the references within all of c's own string values, i.e.
+//those set by c's non layer methods, with c.Interpolator and notifies watchers
+//of the resulting Changes.
+//A nil c.Interpolator is treated as the zero Interpolator.
+//If any reference cannot be resolved, then an *InterpolationError is returned
+//and c is unchanged.
+//
+//Escaped references are unescaped, and so calling Interpolate again resolves them.
+func (c *Config) Interpolate() (err error) {
+	interpolator := c.Interpolator
+	if interpolator == nil {
+		interpolator = &Interpolator{}
+	}
+	c.update(nil, func(v *Values) bool {
+		var changed bool
+		changed, err = interpolator.interpolate(c.KeyParser, v)
+		return changed
+	})
+	return err
+}
+
+//interpolateInto interpolates temp, which is about to be merged into own, with
+//c.Interpolator so that references may refer to c's values or temp's.
+//own is c's own Values and must be locked by the caller.
+func (c *Config) interpolateInto(own, temp *Values) error {
+	if c.Interpolator == nil {
+		return nil
+	}
+	if own != c.values {
+		c.values.lock.RLock()
+		defer c.values.lock.RUnlock()
+	}
+	return c.Interpolator.interpolateInto(c.KeyParser, c.values.root, temp)
+}
+
+//Remove removes a Key value association in c's Values and notifies watchers
+//of the resulting Changes.
 //It returns the value being removed. ok indicates whether or not a value was
 //actually stored at key and was removed.
+//
+//If c is layered, then the value is removed from the RuntimeLayer, and ok also
+//requires that c's resolved value at key changed, i.e. it is false if a lower
+//layer provides the same value.
 func (c *Config) Remove(key string) (value interface{}, ok bool) {
-	return c.values.Remove(c.NewKey(key))
+	k := c.NewKey(key)
+	removed := false
+	changed := c.update(k, func(v *Values) bool {
+		value, removed = v.remove(k)
+		return removed
+	})
+	if !removed || !changed {
+		return nil, false
+	}
+	return value, true
 }
 
 //NewKey returns the Key created by c.KeyParser.Parse(k).
 func (c *Config) NewKey(k string) Key {
 	return c.KeyParser.Parse(k)
 }
+
+//update calls mutate with c's own Values (see c.ownValues()) and notifies
+//watchers of the resulting Changes.
+//key is the Key at and below which mutate makes its changes.
+//mutate must only use the unexported, non locking methods of its argument.
+func (c *Config) update(key Key, mutate func(v *Values) bool) bool {
+	changed, changes := c.apply(key, mutate)
+	c.notify(changes)
+	return changed
+}
+
+//apply calls mutate with c's own Values and returns mutate's result along with
+//the resulting Changes to c's values.
+//Changes are only computed if they are needed, i.e. c is watched or layered.
+func (c *Config) apply(key Key, mutate func(v *Values) bool) (bool, []Change) {
+	c.layerLock.Lock()
+	defer c.layerLock.Unlock()
+
+	own := c.ownValues()
+	if own != c.values {
+		own.lock.Lock()
+		changed := mutate(own)
+		own.unlock()
+
+		if !changed {
+			return false, nil
+		}
+		changes := c.resolve()
+		return len(changes) > 0, changes
+	}
+
+	if !c.isWatched() {
+		c.values.lock.Lock()
+		defer c.values.unlock()
+
+		return mutate(c.values), nil
+	}
+	return c.values.track(key, func() bool {
+		return mutate(c.values)
+	})
+}
+
+//replaceOwn replaces all associations in c's own Values with those in temp and
+//returns the resulting Changes to c's values.
+//temp must not be used by the caller afterwards.
+func (c *Config) replaceOwn(temp *Values) []Change {
+	c.layerLock.Lock()
+	defer c.layerLock.Unlock()
+
+	own := c.ownValues()
+	if own != c.values {
+		own.replace(temp)
+		return c.resolve()
+	}
+	return c.values.replace(temp)
+}
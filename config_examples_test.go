@@ -92,3 +92,56 @@ func ExampleConfig_GetValuesOk() {
 	//false
 	//false
 }
+
+func ExampleConfig_Unmarshal() {
+	c := New()
+
+	c.Put("server.host", "localhost")
+	c.Put("server.port", int64(8080))
+	c.Put("server.tls.enabled", true)
+
+	type TLS struct {
+		Enabled bool `config:"enabled"`
+	}
+	type Server struct {
+		Host string `config:"host"`
+		Port int    `config:"port"`
+		TLS  *TLS   `config:"tls"`
+	}
+
+	var server Server
+	if err := c.Unmarshal("server", &server); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(server.Host, server.Port, server.TLS.Enabled)
+
+	var wrong struct {
+		Port string `config:"port"`
+	}
+	fmt.Println(c.Unmarshal("server", &wrong))
+	//Output:
+	//localhost 8080 true
+	//config: cannot use value of type int64 at key [server port] as type string
+}
+
+func ExampleConfig_MarkSensitive() {
+	c := New()
+	c.Put("db.host", "localhost")
+	c.Put("db.password", "hunter2")
+	c.Put("secrets.api.token", "abc123")
+
+	c.MarkSensitive("*.password", "secrets.**")
+
+	fmt.Println(c)
+	fmt.Println(c.GetString("db.password"))
+
+	c.Watch("db", func(change Change) {
+		fmt.Println(change)
+	})
+	c.Put("db.password", "correct horse")
+	//Output:
+	//map[db:map[host:localhost password:[REDACTED]] secrets:map[api:map[token:[REDACTED]]]]
+	//hunter2
+	//modified [db password]: [REDACTED] -> [REDACTED]
+}
@@ -1,11 +1,17 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -38,7 +44,7 @@ func TestConfig_LoadAll_success(t *testing.T) {
 	}
 
 	want := NewValues()
-	want.Put(NewKey("2"), 2)
+	want.PutSource(NewKey("2"), 2, "config.intLoader")
 
 	if !reflect.DeepEqual(c.values, want) {
 		t.Fail()
@@ -50,9 +56,13 @@ func TestConfig_LoadAll_error(t *testing.T) {
 	c.AddLoaders(intLoader(2), errorLoader("error loading"))
 
 	_, err := c.LoadAll()
-	if err.Error() != "error loading" {
+	loadError, ok := err.(*LoadError)
+	if !ok || loadError.Loader != errorLoader("error loading") || loadError.Err.Error() != "error loading" {
 		t.Fail()
 	}
+	if err.Error() != "config: cannot load config.errorLoader: error loading" {
+		t.Errorf("err = %v", err)
+	}
 }
 
 func TestConfig_Values(t *testing.T) {
@@ -69,6 +79,17 @@ func TestConfig_EqualValues(t *testing.T) {
 	}
 }
 
+func TestConfig_DiffValues(t *testing.T) {
+	c := New()
+	other := New()
+	other.Put("a", "a")
+
+	want := []Change{{Kind: ChangeAdded, Key: NewKey("a"), New: "a"}}
+	if changes := c.DiffValues(other); !reflect.DeepEqual(changes, want) {
+		t.Errorf("c.DiffValues() = %v WANT %v", changes, want)
+	}
+}
+
 func TestConfig_Clone(t *testing.T) {
 	c := New()
 	c.AddLoaders(intLoader(1))
@@ -80,6 +101,22 @@ func TestConfig_Clone(t *testing.T) {
 	}
 }
 
+func TestConfig_Clone_independent(t *testing.T) {
+	c := New()
+	c.Put("a.b", "b")
+
+	result := c.Clone()
+	result.Put("a.b", "changed")
+	c.Put("a.c", "c")
+
+	if b := c.Get("a.b"); b != "b" {
+		t.Errorf("c a.b = %v WANT b", b)
+	}
+	if _, ok := result.GetOk("a.c"); ok {
+		t.Error("result should not have a.c")
+	}
+}
+
 func TestConfig_GetInt64(t *testing.T) {
 	c := New()
 	c.Put("int64", 8)
@@ -228,6 +265,63 @@ func TestConfig_GetFloat64Ok(t *testing.T) {
 	}
 }
 
+func TestConfig_GetAs(t *testing.T) {
+	c := New()
+	c.Put("port", "8080")
+	c.Put("enabled", "yes")
+	c.Put("ratio", "0.5")
+	c.Put("int", 12)
+	c.Put("bad", "bad")
+
+	if i, err := c.GetInt64As("port"); i != 8080 || err != nil {
+		t.Errorf("c.GetInt64As() = %v, %v", i, err)
+	}
+	if b, err := c.GetBoolAs("enabled"); !b || err != nil {
+		t.Errorf("c.GetBoolAs() = %v, %v", b, err)
+	}
+	if f, err := c.GetFloat64As("ratio"); f != 0.5 || err != nil {
+		t.Errorf("c.GetFloat64As() = %v, %v", f, err)
+	}
+	if s, err := c.GetStringAs("int"); s != "12" || err != nil {
+		t.Errorf("c.GetStringAs() = %v, %v", s, err)
+	}
+
+	if _, err := c.GetInt64As("bad"); err == nil {
+		t.Error("c.GetInt64As(bad) should error")
+	} else if _, ok := err.(*TypeError); !ok {
+		t.Errorf("c.GetInt64As(bad) = %T WANT *TypeError", err)
+	}
+	if _, err := c.GetBoolAs("bad"); err == nil {
+		t.Error("c.GetBoolAs(bad) should error")
+	}
+	if _, err := c.GetFloat64As("bad"); err == nil {
+		t.Error("c.GetFloat64As(bad) should error")
+	}
+
+	c.Put("db.host", "h")
+	c.Put("db.password", "secret")
+	c.MarkSensitive("db.password")
+	if s, err := c.GetStringAs("db"); s != "" || err == nil {
+		t.Errorf("c.GetStringAs(db) = %q, %v WANT *TypeError", s, err)
+	} else if typeErr, ok := err.(*TypeError); !ok || typeErr.Got != valuesType {
+		t.Errorf("c.GetStringAs(db) = %#v WANT *TypeError of *Values", err)
+	}
+
+	missing := []func(string) error{
+		func(k string) error { _, err := c.GetInt64As(k); return err },
+		func(k string) error { _, err := c.GetBoolAs(k); return err },
+		func(k string) error { _, err := c.GetFloat64As(k); return err },
+		func(k string) error { _, err := c.GetStringAs(k); return err },
+	}
+	for index, getAs := range missing {
+		err := getAs("does.not.exist")
+		notFound, ok := err.(*KeyNotFoundError)
+		if !ok || !notFound.Key.Equal(NewKey("does", "not", "exist")) {
+			t.Errorf("%v, err = %v WANT *KeyNotFoundError", index, err)
+		}
+	}
+}
+
 func TestConfig_GetValues(t *testing.T) {
 	c := New()
 	c.Put("a.b", "b")
@@ -268,6 +362,27 @@ func TestConfig_GetValuesOk(t *testing.T) {
 	}
 }
 
+func TestConfig_GetSlice(t *testing.T) {
+	c := New()
+	c.Put("users.0.name", "a")
+	c.Put("users.1.name", "b")
+	c.Put("ids", []int{1, 2})
+
+	if length := c.Len("users"); length != 2 {
+		t.Errorf("c.Len(users) = %v WANT 2", length)
+	}
+	if ids := c.GetSlice("ids"); !reflect.DeepEqual(ids, []interface{}{1, 2}) {
+		t.Errorf("c.GetSlice(ids) = %v", ids)
+	}
+	users := c.GetValuesSlice("users")
+	if len(users) != 2 || users[1].Get(NewKey("name")) != "b" {
+		t.Errorf("c.GetValuesSlice(users) = %v", users)
+	}
+	if _, ok := c.GetValuesSliceOk("ids"); ok {
+		t.Error("c.GetValuesSliceOk(ids) should not be ok")
+	}
+}
+
 func TestConfig_Get(t *testing.T) {
 	c := getFullConfig()
 	tests := getFullConfigTests()
@@ -319,6 +434,21 @@ func TestConfig_Merge(t *testing.T) {
 	c.Merge(New())
 }
 
+func TestConfig_Merge_self(t *testing.T) {
+	c := New()
+	c.Put("a", "a")
+	c.Watch("", func(Change) {})
+
+	withoutDeadlock(t, func() {
+		c.Merge(c)
+		c.Put("b", c.Values())
+	})
+
+	if a, b := c.Get("a"), c.Get("b.a"); a != "a" || b != "a" {
+		t.Errorf("a, b.a = %v, %v WANT a, a", a, b)
+	}
+}
+
 func getFullConfig() *Config {
 	c := New()
 
@@ -450,6 +580,311 @@ func TestConfig_MergeLoaders_error(t *testing.T) {
 	}
 }
 
+func TestConfig_MergeLoadersContext(t *testing.T) {
+	c := New()
+	changed, err := c.MergeLoadersContext(context.Background(), intLoader(1), contextLoader{})
+	if !changed || err != nil || c.GetInt64("1") != 1 || !c.GetBool("context") {
+		t.Errorf("MergeLoadersContext() = %v, %v WANT true, nil", changed, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	block := blockingLoader(make(chan struct{}))
+	defer close(block)
+	changed, err = c.MergeLoadersContext(ctx, intLoader(2), block)
+	if changed || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("MergeLoadersContext() = %v, %v WANT false, %v", changed, err, context.DeadlineExceeded)
+	}
+	if _, ok := c.GetOk("2"); ok {
+		t.Error("c should be unchanged")
+	}
+}
+
+func TestConfig_ConcurrentLoading(t *testing.T) {
+	c := New()
+	c.ConcurrentLoading = true
+
+	started := &sync.WaitGroup{}
+	started.Add(2)
+	first := barrierLoader{started, newLayerValues("a", 1, "b", 1)}
+	second := barrierLoader{started, newLayerValues("a", 2)}
+
+	withoutDeadlock(t, func() {
+		if _, err := c.MergeLoaders(first, second); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if a, b := c.GetInt64("a"), c.GetInt64("b"); a != 2 || b != 1 {
+		t.Errorf("a, b = %v, %v WANT 2, 1", a, b)
+	}
+	if source, _ := c.Source("a"); source != "config.barrierLoader" {
+		t.Errorf("source = %q", source)
+	}
+
+	withoutDeadlock(t, func() {
+		_, err := c.MergeLoaders(contextLoader{}, errorLoader("first"), contextLoader{block: true}, errorLoader("second"))
+		if loadError, ok := err.(*LoadError); !ok || loadError.Loader != errorLoader("first") {
+			t.Errorf("error = %v WANT first", err)
+		}
+	})
+	if _, ok := c.GetOk("context"); ok {
+		t.Error("c should be unchanged")
+	}
+}
+
+//barrierLoader is a Loader whose Load() returns values only once all other
+//barrierLoaders with the same started have started loading.
+type barrierLoader struct {
+	started *sync.WaitGroup
+	values  *Values
+}
+
+func (l barrierLoader) Load() (*Values, error) {
+	l.started.Done()
+	l.started.Wait()
+	return l.values.Clone(), nil
+}
+
+func TestConfig_MergeLoaders_policies(t *testing.T) {
+	missing := NewFileFuncLoader(func(io.Reader) (*Values, error) { return NewValues(), nil }, "/does/not/exist")
+	c := New()
+
+	changed, err := c.MergeLoaders(
+		intLoader(1),
+		WithPolicy(errorLoader("optional"), LoadOptional),
+		WithPolicy(missing, LoadIgnoreMissing),
+		intLoader(2),
+	)
+	if !changed || err != nil || c.GetInt64("1") != 1 || c.GetInt64("2") != 2 {
+		t.Errorf("MergeLoaders() = %v, %v WANT true, nil", changed, err)
+	}
+
+	_, err = c.MergeLoaders(WithPolicy(errorLoader("required"), LoadIgnoreMissing), intLoader(3))
+	if loadError, ok := err.(*LoadError); !ok || loadError.Err.Error() != "required" {
+		t.Errorf("MergeLoaders() error = %v WANT required", err)
+	}
+	if _, ok := c.GetOk("3"); ok {
+		t.Error("c should be unchanged")
+	}
+}
+
+func TestConfig_MergeLoaders_bestEffort(t *testing.T) {
+	missing := NewFileFuncLoader(func(io.Reader) (*Values, error) { return NewValues(), nil }, "/does/not/exist")
+	for _, concurrent := range []bool{false, true} {
+		c := New()
+		c.BestEffort = true
+		c.ConcurrentLoading = concurrent
+
+		changed, err := c.MergeLoaders(
+			intLoader(1),
+			errorLoader("first"),
+			WithPolicy(errorLoader("optional"), LoadOptional),
+			missing,
+			intLoader(2),
+		)
+		if !changed || c.GetInt64("1") != 1 || c.GetInt64("2") != 2 {
+			t.Errorf("MergeLoaders() changed = %v, values = %v", changed, c.Values())
+		}
+
+		loadErrors, ok := err.(*LoadErrors)
+		if !ok || len(loadErrors.Errors) != 2 {
+			t.Fatalf("MergeLoaders() error = %v WANT 2 *LoadErrors", err)
+		}
+		if loadErrors.Errors[0].Loader != errorLoader("first") || loadErrors.Errors[1].Loader != missing {
+			t.Errorf("MergeLoaders() errors = %v", loadErrors.Errors)
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Error("errors.Is(err, os.ErrNotExist) should be true")
+		}
+		var loadError *LoadError
+		if !errors.As(err, &loadError) || loadError != loadErrors.Errors[0] {
+			t.Error("errors.As(err, *LoadError) should find the first *LoadError")
+		}
+	}
+}
+
+func TestConfig_Reload_bestEffort(t *testing.T) {
+	c := New().AddLoaders(intLoader(1), errorLoader("error"))
+	c.BestEffort = true
+	c.Put("a", "a")
+
+	changes, err := c.Reload()
+	if _, ok := err.(*LoadErrors); !ok {
+		t.Errorf("Reload() error = %v WANT *LoadErrors", err)
+	}
+	if len(changes) != 2 || c.GetInt64("1") != 1 {
+		t.Errorf("Reload() = %v WANT 2 Changes", changes)
+	}
+}
+
+func TestConfig_Reload_bestEffortKeepsFailed(t *testing.T) {
+	values := NewValues()
+	values.Put(NewKey("b"), "b")
+	loader := &failingLoader{values: values}
+
+	c := New().AddLoaders(intLoader(1), loader)
+	c.BestEffort = true
+	if _, err := c.LoadAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	loader.err = fmt.Errorf("failing")
+	changes, err := c.Reload()
+	if _, ok := err.(*LoadErrors); !ok {
+		t.Errorf("Reload() error = %v WANT *LoadErrors", err)
+	}
+	if len(changes) != 0 || c.Get("b") != "b" || c.GetInt64("1") != 1 {
+		t.Errorf("Reload() = %v, values = %v WANT the previous values", changes, c.Values())
+	}
+	if source, _ := c.Source("b"); source != "failing" {
+		t.Errorf("c.Source(b) = %v WANT failing", source)
+	}
+
+	c.Remove("b")
+	if _, err := c.LoadAll(); err == nil || c.Get("b") != "b" {
+		t.Errorf("LoadAll() error = %v, b = %v WANT the previous values", err, c.Get("b"))
+	}
+
+	loader.err = nil
+	loader.values = NewValues()
+	if changes, err := c.Reload(); err != nil || len(changes) != 1 || c.Get("b") != nil {
+		t.Errorf("Reload() = %v, %v WANT b removed", changes, err)
+	}
+
+	loader.err = fmt.Errorf("failing")
+	if _, err := c.Reload(); err == nil || c.Get("b") != nil || c.GetInt64("1") != 1 {
+		t.Errorf("Reload() error = %v, values = %v WANT unchanged", err, c.Values())
+	}
+}
+
+func TestConfig_MergeLoadersContext_optionalCanceled(t *testing.T) {
+	c := New()
+	c.BestEffort = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.MergeLoadersContext(ctx, WithPolicy(contextLoader{block: true}, LoadOptional))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("MergeLoadersContext() error = %v WANT %v", err, context.Canceled)
+	}
+}
+
+func TestConfig_LoadAllContext(t *testing.T) {
+	c := New().AddLoaders(intLoader(1), contextLoader{block: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.LoadAllContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LoadAllContext() error = %v WANT %v", err, context.DeadlineExceeded)
+	}
+	if !c.Values().IsEmpty() {
+		t.Error("c should be unchanged")
+	}
+}
+
+func TestConfig_ReloadContext(t *testing.T) {
+	c := New().AddLoaders(intLoader(1))
+	c.Put("a", "a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.ReloadContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReloadContext() error = %v WANT %v", err, context.Canceled)
+	}
+	if c.Get("a") != "a" {
+		t.Error("c should be unchanged")
+	}
+
+	if _, err := c.ReloadContext(context.Background()); err != nil || c.GetInt64("1") != 1 {
+		t.Errorf("ReloadContext() error = %v WANT nil", err)
+	}
+}
+
+func TestConfig_Reload(t *testing.T) {
+	values := NewValues()
+	values.Put(NewKey("a"), "a")
+	values.Put(NewKey("b"), "b")
+	loader := valuesLoader{values}
+
+	c := New()
+	c.AddLoaders(&loader)
+	if _, err := c.LoadAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	var watched []Change
+	c.Watch("", func(change Change) { watched = append(watched, change) })
+
+	loader.values = NewValues()
+	loader.values.Put(NewKey("a"), "new a")
+	loader.values.Put(NewKey("c"), "c")
+
+	changes, err := c.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Change{
+		{Kind: ChangeModified, Key: NewKey("a"), Old: "a", New: "new a"},
+		{Kind: ChangeRemoved, Key: NewKey("b"), Old: "b"},
+		{Kind: ChangeAdded, Key: NewKey("c"), New: "c"},
+	}
+	if !reflect.DeepEqual(changes, want) || !reflect.DeepEqual(watched, want) {
+		t.Errorf("c.Reload() = %v, watched %v WANT %v", changes, watched, want)
+	}
+	if !c.Values().Equal(loader.values) {
+		t.Error("c.Values() should equal the reloaded values")
+	}
+
+	changes, err = c.Reload()
+	if changes != nil || err != nil {
+		t.Errorf("c.Reload() = %v, %v WANT no changes", changes, err)
+	}
+}
+
+func TestConfig_Reload_error(t *testing.T) {
+	c := New()
+	c.Put("a", "a")
+	c.AddLoaders(intLoader(1), errorLoader("error"))
+
+	changes, err := c.Reload()
+	if changes != nil || err == nil {
+		t.Errorf("c.Reload() = %v, %v WANT error", changes, err)
+	}
+	if c.Get("a") != "a" {
+		t.Error("c should not change when Reload errors")
+	}
+}
+
+func TestConfig_Source(t *testing.T) {
+	sourced := NewValues()
+	sourced.PutSource(NewKey("db", "host"), "localhost", "env APP_DB_HOST")
+	sourced.Put(NewKey("db", "port"), 5432)
+
+	c := New()
+	c.MergeLoaders(intLoader(1), &valuesLoader{sourced})
+	c.Put("runtime", true)
+
+	tests := []struct {
+		key    string
+		source string
+		ok     bool
+	}{
+		{"1", "config.intLoader", true},
+		{"db.host", "env APP_DB_HOST", true},
+		{"db.port", "*config.valuesLoader", true},
+		{"db", "", false},
+		{"runtime", "", false},
+		{"missing", "", false},
+	}
+	for _, test := range tests {
+		source, ok := c.Source(test.key)
+		if source != test.source || ok != test.ok {
+			t.Errorf("c.Source(%v) = %v, %v WANT %v, %v", test.key, source, ok, test.source, test.ok)
+		}
+	}
+}
+
 func TestConfig_Remove(t *testing.T) {
 	c := New()
 	c.Put("a", "a")
@@ -492,6 +927,32 @@ func (l intLoader) Load() (*Values, error) {
 	return v, nil
 }
 
+type valuesLoader struct {
+	values *Values
+}
+
+func (l *valuesLoader) Load() (*Values, error) {
+	return l.values.Clone(), nil
+}
+
+//failingLoader is a Loader that returns err if it is not nil and a clone of
+//values otherwise.
+type failingLoader struct {
+	values *Values
+	err    error
+}
+
+func (l *failingLoader) Load() (*Values, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	return l.values.Clone(), nil
+}
+
+func (l *failingLoader) Name() string {
+	return "failing"
+}
+
 type errorLoader string
 
 func (l errorLoader) Load() (*Values, error) {
@@ -0,0 +1,349 @@
+package config
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//TagName is the struct field tag key used to name the Key part a field is
+//decoded from.
+//
+//	type Server struct {
+//		Host    string `config:"host"`
+//		Port    int    `config:"port"`
+//		Ignored string `config:"-"`
+//	}
+const TagName = "config"
+
+var (
+	valuesType          = reflect.TypeOf((*Values)(nil))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+//Decode is sugar for v.DecodeKey(Key(nil), out).
+func (v *Values) Decode(out interface{}) error {
+	return v.DecodeKey(nil, out)
+}
+
+//DecodeKey decodes the associations stored at key into the value pointed to by out.
+//out must be a non-nil pointer, otherwise an *InvalidDecodeError is returned.
+//If nothing is stored at key, then out is left untouched and nil is returned.
+//
+//Subtrees of v are decoded into structs, maps with string keys, slices and arrays
+//(from subtrees whose Key parts are exactly the indices "0" through "n-1"), and
+//*Values.
+//Struct fields are matched to Key parts by their TagName tag or, without a tag,
+//by their name. An exact match is preferred over a case insensitive one, and of
+//several case insensitive matches the least Key part is used.
+//Fields tagged with "-" and unexported fields are skipped.
+//Untagged embedded structs have their fields decoded as if they were fields
+//of the outer struct.
+//
+//Single values are decoded into anything they are assignable to, into any
+//numeric type whose range holds them, into slices and arrays from slice values,
+//and into encoding.TextUnmarshalers from strings.
+//Pointers are allocated as needed, and empty interfaces receive the raw value
+//(or a []interface{} for lists and a map[string]interface{} for other subtrees).
+//Empty subtrees, such as the empty elements of a list (see PutList), leave
+//anything else untouched.
+//
+//If a value cannot be converted, then a *TypeError with the full Key of the value
+//is returned and decoding stops.
+func (v *Values) DecodeKey(key Key, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidDecodeError{reflect.TypeOf(out)}
+	}
+
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	found := v.root.descendent(key)
+	if found == nil {
+		return nil
+	}
+	return decoder{}.decodeNode(NewKey(key...), found, rv.Elem())
+}
+
+//decoder decodes nodes into reflect.Values.
+type decoder struct {
+	//coerce tells decoder to convert single values into bools and numbers as the
+	//Config Get*As methods do, e.g. the string "80" into an int.
+	coerce bool
+}
+
+//decodeNode decodes n, which is stored at key, into out.
+func (d decoder) decodeNode(key Key, n *node, out reflect.Value) error {
+	if n.isSet() {
+		return d.decodeValue(key, n.value, out)
+	}
+	return d.decodeTree(key, n, out)
+}
+
+//decodeTree decodes the non set n into out.
+func (d decoder) decodeTree(key Key, n *node, out reflect.Value) error {
+	if out.Type() == valuesType {
+		out.Set(reflect.ValueOf(newValues(n.clone())))
+		return nil
+	}
+	switch out.Kind() {
+	case reflect.Ptr:
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+		return d.decodeTree(key, n, out.Elem())
+	case reflect.Interface:
+		if out.NumMethod() == 0 {
+			out.Set(reflect.ValueOf(n.interfaceValue()))
+			return nil
+		}
+	case reflect.Struct:
+		return d.decodeStruct(key, n, out)
+	case reflect.Map:
+		return d.decodeMap(key, n, out)
+	case reflect.Slice, reflect.Array:
+		return d.decodeIndexed(key, n, out)
+	}
+	if n.isEmpty() {
+		return nil
+	}
+	return &TypeError{Key: key, Want: out.Type(), Got: valuesType}
+}
+
+func (d decoder) decodeStruct(key Key, n *node, out reflect.Value) error {
+	t := out.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, tagged := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && !tagged && field.Type.Kind() == reflect.Struct {
+			if err := d.decodeStruct(key, n, out.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		keyPart, child := n.matchChild(name)
+		if child == nil {
+			continue
+		}
+		if err := d.decodeNode(key.AppendStrings(keyPart), child, out.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//fieldName returns the Key part that field is decoded from and whether or not
+//that name came from a TagName tag.
+func fieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get(TagName)
+	if index := strings.Index(tag, ","); index >= 0 {
+		tag = tag[:index]
+	}
+	if tag != "" {
+		return tag, true
+	}
+	return field.Name, false
+}
+
+func (d decoder) decodeMap(key Key, n *node, out reflect.Value) error {
+	t := out.Type()
+	if t.Key().Kind() != reflect.String {
+		return &TypeError{Key: key, Want: t, Got: valuesType}
+	}
+	if out.IsNil() {
+		out.Set(reflect.MakeMap(t))
+	}
+	for keyPart, child := range n.children {
+		elem := reflect.New(t.Elem()).Elem()
+		if err := d.decodeNode(key.AppendStrings(keyPart), child, elem); err != nil {
+			return err
+		}
+		out.SetMapIndex(reflect.ValueOf(keyPart).Convert(t.Key()), elem)
+	}
+	return nil
+}
+
+//decodeIndexed decodes the children of n, which must be a list (see node.listLen()),
+//into the slice or array out.
+func (d decoder) decodeIndexed(key Key, n *node, out reflect.Value) error {
+	length, ok := n.listLen()
+	if !ok {
+		return &TypeError{Key: key, Want: out.Type(), Got: valuesType}
+	}
+	if out.Kind() == reflect.Slice {
+		out.Set(reflect.MakeSlice(out.Type(), length, length))
+	} else {
+		out.Set(reflect.Zero(out.Type()))
+	}
+	for index := 0; index < length && index < out.Len(); index++ {
+		keyPart := strconv.Itoa(index)
+		if err := d.decodeNode(key.AppendStrings(keyPart), n.children[keyPart], out.Index(index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//decodeValue decodes the single value stored at key into out.
+func (d decoder) decodeValue(key Key, value interface{}, out reflect.Value) error {
+	if value == nil {
+		out.Set(reflect.Zero(out.Type()))
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Type().AssignableTo(out.Type()) {
+		out.Set(rv)
+		return nil
+	}
+	if out.Kind() == reflect.Ptr {
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+		return d.decodeValue(key, value, out.Elem())
+	}
+	if rv.Kind() == reflect.String && out.CanAddr() && out.Addr().Type().Implements(textUnmarshalerType) {
+		err := out.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(rv.String()))
+		if err != nil {
+			return &TypeError{Key: key, Want: out.Type(), Got: rv.Type(), Err: err}
+		}
+		return nil
+	}
+
+	if d.coerce {
+		if coerced, err := coerceInto(key, value, out); coerced {
+			return err
+		}
+	}
+
+	typeError := &TypeError{Key: key, Want: out.Type(), Got: rv.Type()}
+	switch out.Kind() {
+	case reflect.Bool:
+		if rv.Kind() != reflect.Bool {
+			return typeError
+		}
+		out.SetBool(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := int64Value(rv)
+		if !ok || out.OverflowInt(i) {
+			return typeError
+		}
+		out.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, ok := uint64Value(rv)
+		if !ok || out.OverflowUint(u) {
+			return typeError
+		}
+		out.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, ok := float64Value(rv)
+		if !ok || out.OverflowFloat(f) {
+			return typeError
+		}
+		out.SetFloat(f)
+	case reflect.String:
+		if rv.Kind() != reflect.String {
+			return typeError
+		}
+		out.SetString(rv.String())
+	case reflect.Slice:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return typeError
+		}
+		out.Set(reflect.MakeSlice(out.Type(), rv.Len(), rv.Len()))
+		return d.decodeElements(key, rv, out)
+	case reflect.Array:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return typeError
+		}
+		out.Set(reflect.Zero(out.Type()))
+		return d.decodeElements(key, rv, out)
+	case reflect.Map:
+		return d.decodeMapValue(key, rv, out, typeError)
+	default:
+		return typeError
+	}
+	return nil
+}
+
+//decodeElements decodes each element of the slice or array rv into the same
+//index of out. Elements beyond the length of out are ignored.
+func (d decoder) decodeElements(key Key, rv, out reflect.Value) error {
+	for i := 0; i < rv.Len() && i < out.Len(); i++ {
+		elemKey := key.AppendStrings(strconv.Itoa(i))
+		if err := d.decodeValue(elemKey, rv.Index(i).Interface(), out.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d decoder) decodeMapValue(key Key, rv, out reflect.Value, typeError error) error {
+	t := out.Type()
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String || t.Key().Kind() != reflect.String {
+		return typeError
+	}
+	if out.IsNil() {
+		out.Set(reflect.MakeMap(t))
+	}
+	iter := rv.MapRange()
+	for iter.Next() {
+		keyPart := iter.Key().String()
+		elem := reflect.New(t.Elem()).Elem()
+		if err := d.decodeValue(key.AppendStrings(keyPart), iter.Value().Interface(), elem); err != nil {
+			return err
+		}
+		out.SetMapIndex(reflect.ValueOf(keyPart).Convert(t.Key()), elem)
+	}
+	return nil
+}
+
+//int64Value returns rv as an int64 if rv is an integer type that fits in an int64.
+func int64Value(rv reflect.Value) (int64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if int64(u) < 0 {
+			return 0, false
+		}
+		return int64(u), true
+	}
+	return 0, false
+}
+
+//uint64Value returns rv as a uint64 if rv is a non negative integer type.
+func uint64Value(rv reflect.Value) (uint64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := rv.Int()
+		if i < 0 {
+			return 0, false
+		}
+		return uint64(i), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), true
+	}
+	return 0, false
+}
+
+//float64Value returns rv as a float64 if rv is a floating point or integer type.
+func float64Value(rv reflect.Value) (float64, bool) {
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+type decodeServer struct {
+	Host       string `config:"host"`
+	Port       int    `config:"port"`
+	Ignored    string `config:"-"`
+	Timeout    *float32
+	unexported string
+}
+
+type decodeEmbedded struct {
+	Name string `config:"name"`
+}
+
+type decodeApp struct {
+	decodeEmbedded
+
+	Server   decodeServer             `config:"server"`
+	Backup   *decodeServer            `config:"backup"`
+	Ports    []uint16                 `config:"ports"`
+	Tags     []string                 `config:"tags"`
+	Limits   map[string]int64         `config:"limits"`
+	Raw      interface{}              `config:"raw"`
+	Tree     interface{}              `config:"tree"`
+	Values   *Values                  `config:"values"`
+	IP       net.IP                   `config:"ip"`
+	Pair     [2]int                   `config:"pair"`
+	Nested   map[string]*decodeServer `config:"nested"`
+	Missing  string                   `config:"missing"`
+	Settings map[string]interface{}   `config:"settings"`
+}
+
+func TestValues_Decode(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("name"), "app")
+	v.Put(NewKey("server", "host"), "localhost")
+	v.Put(NewKey("server", "port"), int64(8080))
+	v.Put(NewKey("server", "Ignored"), "ignored")
+	v.Put(NewKey("server", "timeout"), 1.5)
+	v.Put(NewKey("backup", "host"), "backup")
+	v.Put(NewKey("ports", "0"), 80)
+	v.Put(NewKey("ports", "1"), uint8(81))
+	v.Put(NewKey("tags"), []interface{}{"a", "b"})
+	v.Put(NewKey("limits", "a"), 1)
+	v.Put(NewKey("limits", "b"), int32(2))
+	v.Put(NewKey("raw"), true)
+	v.Put(NewKey("tree", "a", "b"), "b")
+	v.Put(NewKey("values", "a"), "a")
+	v.Put(NewKey("ip"), "127.0.0.1")
+	v.Put(NewKey("pair"), []int{1, 2, 3})
+	v.Put(NewKey("nested", "one", "port"), 1)
+	v.Put(NewKey("settings"), map[string]interface{}{"a": 1})
+
+	var app decodeApp
+	if err := v.Decode(&app); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := float32(1.5)
+	valuesValues := NewValues()
+	valuesValues.Put(NewKey("a"), "a")
+	want := decodeApp{
+		decodeEmbedded: decodeEmbedded{"app"},
+		Server:         decodeServer{Host: "localhost", Port: 8080, Timeout: &timeout},
+		Backup:         &decodeServer{Host: "backup"},
+		Ports:          []uint16{80, 81},
+		Tags:           []string{"a", "b"},
+		Limits:         map[string]int64{"a": 1, "b": 2},
+		Raw:            true,
+		Tree:           map[string]interface{}{"a": map[string]interface{}{"b": "b"}},
+		Values:         valuesValues,
+		IP:             net.ParseIP("127.0.0.1"),
+		Pair:           [2]int{1, 2},
+		Nested:         map[string]*decodeServer{"one": {Port: 1}},
+		Settings:       map[string]interface{}{"a": 1},
+	}
+	if !reflect.DeepEqual(app, want) {
+		t.Errorf("v.Decode() = %+v WANT %+v", app, want)
+	}
+}
+
+func TestValues_Decode_caseInsensitive(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("host"), "lower")
+	v.Put(NewKey("port"), 1)
+
+	var s struct {
+		Host string
+		Port int
+	}
+	if err := v.Decode(&s); err != nil || s.Host != "lower" || s.Port != 1 {
+		t.Errorf("v.Decode() = %v, %+v", err, s)
+	}
+}
+
+func TestValues_Decode_caseInsensitiveAmbiguous(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("hOST"), "hOST")
+	v.Put(NewKey("hoST"), "hoST")
+	v.Put(NewKey("HOST"), "HOST")
+
+	for i := 0; i < 10; i++ {
+		var s struct{ Host string }
+		if err := v.Decode(&s); err != nil || s.Host != "HOST" {
+			t.Fatalf("v.Decode() = %v, %+v WANT HOST", err, s)
+		}
+	}
+}
+
+func TestValues_Decode_singleValue(t *testing.T) {
+	v := NewValues()
+	v.Put(nil, "root")
+
+	var s string
+	if err := v.Decode(&s); err != nil || s != "root" {
+		t.Errorf("v.Decode() = %v, %v", err, s)
+	}
+}
+
+func TestValues_Decode_invalid(t *testing.T) {
+	v := NewValues()
+	var s string
+	tests := []interface{}{nil, s, (*string)(nil)}
+	for _, test := range tests {
+		err := v.Decode(test)
+		if _, ok := err.(*InvalidDecodeError); !ok {
+			t.Errorf("v.Decode(%#v) = %v WANT *InvalidDecodeError", test, err)
+		}
+	}
+}
+
+func TestValues_DecodeKey_typeError(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("a", "string"), "string")
+	v.Put(NewKey("a", "big"), 300)
+	v.Put(NewKey("a", "negative"), -1)
+	v.Put(NewKey("a", "float"), 1.5)
+	v.Put(NewKey("a", "tree", "b"), "b")
+	v.Put(NewKey("a", "notIndexed", "b"), "b")
+	v.Put(NewKey("a", "sparse", "0"), "0")
+	v.Put(NewKey("a", "sparse", "999999999"), "999999999")
+	v.Put(NewKey("a", "slice"), []interface{}{1, "two"})
+
+	tests := []struct {
+		out  interface{}
+		key  Key
+		want reflect.Type
+		got  reflect.Type
+	}{
+		{&struct{ String int }{}, NewKey("a", "string"), reflect.TypeOf(0), reflect.TypeOf("")},
+		{&struct{ Big int8 }{}, NewKey("a", "big"), reflect.TypeOf(int8(0)), reflect.TypeOf(0)},
+		{&struct{ Negative uint }{}, NewKey("a", "negative"), reflect.TypeOf(uint(0)), reflect.TypeOf(0)},
+		{&struct{ Float int }{}, NewKey("a", "float"), reflect.TypeOf(0), reflect.TypeOf(0.0)},
+		{&struct{ Tree string }{}, NewKey("a", "tree"), reflect.TypeOf(""), valuesType},
+		{&struct{ NotIndexed []string }{}, NewKey("a", "notIndexed"), reflect.TypeOf([]string{}), valuesType},
+		{&struct{ Sparse []string }{}, NewKey("a", "sparse"), reflect.TypeOf([]string{}), valuesType},
+		{&struct{ Sparse [2]string }{}, NewKey("a", "sparse"), reflect.TypeOf([2]string{}), valuesType},
+		{&struct{ Slice []int }{}, NewKey("a", "slice", "1"), reflect.TypeOf(0), reflect.TypeOf("")},
+	}
+	for _, test := range tests {
+		err := v.DecodeKey(NewKey("a"), test.out)
+		typeErr, ok := err.(*TypeError)
+		if !ok {
+			t.Errorf("v.DecodeKey(%T) = %v WANT *TypeError", test.out, err)
+			continue
+		}
+		if !typeErr.Key.Equal(test.key) || typeErr.Want != test.want || typeErr.Got != test.got {
+			t.Errorf("v.DecodeKey(%T) = %+v WANT %v, %v, %v", test.out, typeErr, test.key, test.want, test.got)
+		}
+	}
+}
+
+func TestValues_DecodeKey_missing(t *testing.T) {
+	v := NewValues()
+	s := "untouched"
+	if err := v.DecodeKey(NewKey("missing"), &s); err != nil || s != "untouched" {
+		t.Errorf("v.DecodeKey() = %v, %v", err, s)
+	}
+}
+
+func TestConfig_Unmarshal(t *testing.T) {
+	c := New()
+	c.Put("server.host", "localhost")
+	c.Put("server.port", 80)
+
+	var s decodeServer
+	if err := c.Unmarshal("server", &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Host != "localhost" || s.Port != 80 {
+		t.Errorf("c.Unmarshal() = %+v", s)
+	}
+
+	var all struct {
+		Server decodeServer `config:"server"`
+	}
+	if err := c.UnmarshalKey(nil, &all); err != nil || all.Server != s {
+		t.Errorf("c.UnmarshalKey() = %v, %+v", err, all)
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import "reflect"
+
+//Equaler may be implemented by values stored in Values to define their own
+//equality. See DefaultEqual.
+type Equaler interface {
+	//Equal determines whether or not the receiver is equal to other.
+	Equal(other interface{}) bool
+}
+
+//EqualFunc determines whether or not two values stored in Values are equal.
+//It is used to decide whether a put actually changes a value, by Values.Equal(),
+//and when computing Changes.
+//It is only called with non nil values stored at the same Key.
+type EqualFunc func(a, b interface{}) bool
+
+//DefaultEqual is the EqualFunc used by Values unless one is set with
+//Values.SetEqualFunc().
+//
+//If a implements Equaler, then a.Equal(b) is returned.
+//Otherwise a and b must have identical types to be equal. Values of comparable
+//types are compared with the == operator, and all others, such as slices and
+//maps, are compared with reflect.DeepEqual().
+//DefaultEqual never panics due to uncomparable values.
+func DefaultEqual(a, b interface{}) bool {
+	if equaler, ok := a.(Equaler); ok {
+		return equaler.Equal(b)
+	}
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false
+	}
+	if ta.Comparable() {
+		if equal, ok := safeEqual(a, b); ok {
+			return equal
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+//safeEqual returns a == b and true, or false and false if a == b panics.
+//This happens for comparable types, such as structs with interface fields,
+//that hold uncomparable values.
+func safeEqual(a, b interface{}) (equal bool, ok bool) {
+	defer func() {
+		if recover() != nil {
+			equal, ok = false, false
+		}
+	}()
+	return a == b, true
+}
+
+//SetEqualFunc sets the EqualFunc used by v.
+//A nil equal resets v to use DefaultEqual.
+//The EqualFunc is kept by v.Clone() and the subtree Values returned by v.Get().
+func (v *Values) SetEqualFunc(equal EqualFunc) {
+	v.lock.Lock()
+	defer v.unlock()
+
+	v.equal = equal
+}
+
+//equalFunc returns the EqualFunc used by v.
+//A custom EqualFunc is only called with non nil values, and nil values are only
+//equal to each other.
+//v.lock must be held by the caller.
+func (v *Values) equalFunc() EqualFunc {
+	equal := v.equal
+	if equal == nil {
+		return DefaultEqual
+	}
+	return func(a, b interface{}) bool {
+		if a == nil || b == nil {
+			return a == nil && b == nil
+		}
+		return equal(a, b)
+	}
+}
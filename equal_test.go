@@ -0,0 +1,155 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+type caseInsensitive string
+
+func (c caseInsensitive) Equal(other interface{}) bool {
+	o, ok := other.(caseInsensitive)
+	return ok && strings.EqualFold(string(c), string(o))
+}
+
+type withInterface struct {
+	value interface{}
+}
+
+func TestDefaultEqual(t *testing.T) {
+	a, b := 1, 1
+
+	tests := []struct {
+		a, b  interface{}
+		equal bool
+	}{
+		{nil, nil, true},
+		{nil, 1, false},
+		{1, nil, false},
+		{1, 1, true},
+		{1, int64(1), false},
+		{"a", "a", true},
+		{&a, &a, true},
+		{&a, &b, false},
+		{[]interface{}{1, "a"}, []interface{}{1, "a"}, true},
+		{[]interface{}{1, "a"}, []interface{}{1, "b"}, false},
+		{[]int{1}, []interface{}{1}, false},
+		{map[string]interface{}{"a": []int{1}}, map[string]interface{}{"a": []int{1}}, true},
+		{withInterface{[]int{1}}, withInterface{[]int{1}}, true},
+		{withInterface{[]int{1}}, withInterface{[]int{2}}, false},
+		{withInterface{1}, withInterface{1}, true},
+		{caseInsensitive("A"), caseInsensitive("a"), true},
+		{caseInsensitive("A"), "a", false},
+	}
+
+	for i, test := range tests {
+		if equal := DefaultEqual(test.a, test.b); equal != test.equal {
+			t.Errorf("%v: DefaultEqual(%v, %v) = %v WANT %v", i, test.a, test.b, equal, test.equal)
+		}
+	}
+}
+
+func TestValues_Put_uncomparable(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("a"), []interface{}{1, "a"})
+
+	if changed := v.Put(NewKey("a"), []interface{}{1, "a"}); changed {
+		t.Error("putting an equal slice should not be a change")
+	}
+	if changed := v.Put(NewKey("a"), []interface{}{2}); !changed {
+		t.Error("putting a different slice should be a change")
+	}
+	if changed := v.Put(NewKey("a"), map[string]interface{}{"b": []int{1}}); !changed {
+		t.Error("putting a map over a slice should be a change")
+	}
+}
+
+func TestValues_Equal_uncomparable(t *testing.T) {
+	newSliceValues := func(elems ...interface{}) *Values {
+		v := NewValues()
+		v.Put(NewKey("a"), elems)
+		return v
+	}
+
+	if !newSliceValues(1, "a").Equal(newSliceValues(1, "a")) {
+		t.Error("values with equal slices should be equal")
+	}
+	if newSliceValues(1, "a").Equal(newSliceValues(1, "b")) {
+		t.Error("values with different slices should not be equal")
+	}
+	changes := newSliceValues(1).Diff(newSliceValues(2))
+	if len(changes) != 1 || changes[0].Kind != ChangeModified {
+		t.Errorf("Diff() = %v WANT a single modification", changes)
+	}
+}
+
+func TestValues_SetEqualFunc(t *testing.T) {
+	v := NewValues()
+	v.SetEqualFunc(func(a, b interface{}) bool {
+		sa, aok := a.(string)
+		sb, bok := b.(string)
+		if aok && bok {
+			return strings.EqualFold(sa, sb)
+		}
+		return DefaultEqual(a, b)
+	})
+	v.Put(NewKey("a", "b"), "VALUE")
+
+	if changed := v.Put(NewKey("a", "b"), "value"); changed {
+		t.Error("v.Put() should use the EqualFunc")
+	}
+
+	other := NewValues()
+	other.Put(NewKey("a", "b"), "Value")
+	if !v.Equal(other) || len(v.Diff(other)) != 0 {
+		t.Error("v.Equal() and v.Diff() should use the EqualFunc")
+	}
+	if !v.Clone().Equal(other) {
+		t.Error("v.Clone() should keep the EqualFunc")
+	}
+	if sub := v.Get(NewKey("a")).(*Values); sub.Put(NewKey("b"), "vALUE") {
+		t.Error("subtree Values should keep the EqualFunc")
+	}
+
+	v.SetEqualFunc(nil)
+	if changed := v.Put(NewKey("a", "b"), "VaLuE"); !changed {
+		t.Error("v.SetEqualFunc(nil) should restore DefaultEqual")
+	}
+}
+
+func TestValues_SetEqualFunc_onlyValues(t *testing.T) {
+	v := NewValues()
+	v.SetEqualFunc(func(a, b interface{}) bool {
+		return a.(string) == b.(string)
+	})
+	v.Put(NewKey("a", "b"), "b")
+	v.Put(NewKey("c"), nil)
+
+	other := NewValues()
+	other.Put(NewKey("a", "b"), "b")
+	other.Put(NewKey("c"), nil)
+	if !v.Equal(other) || len(v.Diff(other)) != 0 {
+		t.Error("v should equal other")
+	}
+	if changed := v.Put(NewKey("c"), nil); changed {
+		t.Error("v.Put(c, nil) should not change v")
+	}
+	if changed := v.Put(NewKey("c"), "c"); !changed {
+		t.Error("v.Put(c, c) should change v")
+	}
+	if v.Equal(other) {
+		t.Error("v should not equal other")
+	}
+	if changed := v.Merge(NewKey("a"), other); !changed {
+		t.Error("v.Merge() should change v")
+	}
+}
+
+func TestValues_Equaler(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("a"), caseInsensitive("A"))
+
+	if changed := v.Put(NewKey("a"), caseInsensitive("a")); changed {
+		t.Error("v.Put() should use Equaler values")
+	}
+}
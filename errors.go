@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//TypeError describes a value stored at Key that could not be converted into
+//the wanted type.
+type TypeError struct {
+	//Key is the full Key at which the offending value is stored.
+	Key Key
+
+	//Want is the type the value was supposed to be converted into.
+	Want reflect.Type
+
+	//Got is the type of the value actually stored at Key.
+	//It is nil if the stored value is nil.
+	Got reflect.Type
+
+	//Err is the optional underlying error from the attempted conversion,
+	//e.g. a *strconv.NumError.
+	Err error
+}
+
+func (e *TypeError) Error() string {
+	message := fmt.Sprintf("config: cannot use value of type %v at key %v as type %v", e.Got, e.Key, e.Want)
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+//Unwrap returns e.Err.
+func (e *TypeError) Unwrap() error {
+	return e.Err
+}
+
+//KeyNotFoundError describes a lookup of a Key at which no value is stored.
+type KeyNotFoundError struct {
+	Key Key
+}
+
+func (e *KeyNotFoundError) Error() string {
+	return fmt.Sprintf("config: no value at key %v", e.Key)
+}
+
+//UnknownKeyError describes a value stored at a Key that is not declared by a Schema.
+type UnknownKeyError struct {
+	Key Key
+}
+
+func (e *UnknownKeyError) Error() string {
+	return fmt.Sprintf("config: unknown key %v", e.Key)
+}
+
+//ValidationError holds every violation found by Schema.Validate().
+type ValidationError struct {
+	//Errors are the individual violations, which are *KeyNotFoundErrors,
+	//*TypeErrors, and *UnknownKeyErrors.
+	Errors []error
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, strings.TrimPrefix(err.Error(), "config: "))
+	}
+	return fmt.Sprintf("config: %d schema violation(s): %v", len(e.Errors), strings.Join(messages, "; "))
+}
+
+//Unwrap returns e.Errors.
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
+
+//LoadError describes a Loader that failed to load.
+//Errors from Loader.Load() are wrapped in a *LoadError by Config.MergeLoaders()
+//and friends, unless they already are one.
+type LoadError struct {
+	//Loader is the Loader that failed.
+	Loader Loader
+
+	//Path is the optional path of the file that Loader failed to load.
+	Path string
+
+	//Err is the underlying error, e.g. an *os.PathError or a parse error from
+	//the Loader's format that describes the position of the offending input.
+	Err error
+}
+
+func (e *LoadError) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("config: cannot load %v: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("config: cannot load %v: %v", LoaderName(e.Loader), e.Err)
+}
+
+//Unwrap returns e.Err.
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+//LoadErrors holds the errors of every Loader that failed while loading on a
+//best effort basis. See Config.BestEffort.
+type LoadErrors struct {
+	//Errors are the individual failures in the order the Loaders were given.
+	Errors []*LoadError
+}
+
+func (e *LoadErrors) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, strings.TrimPrefix(err.Error(), "config: "))
+	}
+	return fmt.Sprintf("config: %d loader(s) failed: %v", len(e.Errors), strings.Join(messages, "; "))
+}
+
+//Unwrap returns e.Errors.
+func (e *LoadErrors) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+//InvalidDecodeError describes an invalid argument passed to a decoding method.
+//The argument to a decoding method must be a non-nil pointer.
+type InvalidDecodeError struct {
+	Type reflect.Type
+}
+
+func (e *InvalidDecodeError) Error() string {
+	if e.Type == nil {
+		return "config: decode into nil"
+	}
+	if e.Type.Kind() != reflect.Ptr {
+		return "config: decode into non-pointer " + e.Type.String()
+	}
+	return "config: decode into nil " + e.Type.String()
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestErrors_Error(t *testing.T) {
+	tests := []struct {
+		err    error
+		result string
+	}{
+		{
+			&TypeError{Key: NewKey("a", "b"), Want: reflect.TypeOf(0), Got: reflect.TypeOf("")},
+			"config: cannot use value of type string at key [a b] as type int",
+		},
+		{
+			&TypeError{Key: NewKey("a"), Want: reflect.TypeOf(0), Got: nil, Err: errors.New("inner")},
+			"config: cannot use value of type <nil> at key [a] as type int: inner",
+		},
+		{
+			&KeyNotFoundError{Key: NewKey("a", "b")},
+			"config: no value at key [a b]",
+		},
+		{
+			&UnknownKeyError{Key: NewKey("a")},
+			"config: unknown key [a]",
+		},
+		{
+			&ValidationError{Errors: []error{&KeyNotFoundError{NewKey("a")}, &UnknownKeyError{NewKey("b")}}},
+			"config: 2 schema violation(s): no value at key [a]; unknown key [b]",
+		},
+		{
+			&LoadError{Loader: errorLoader("a"), Err: errors.New("inner")},
+			"config: cannot load config.errorLoader: inner",
+		},
+		{
+			&LoadError{Loader: errorLoader("a"), Path: "/etc/app.json", Err: errors.New("inner")},
+			"config: cannot load /etc/app.json: inner",
+		},
+		{
+			&LoadErrors{Errors: []*LoadError{{Loader: intLoader(1), Err: errors.New("a")}, {Loader: errorLoader("b"), Err: errors.New("b")}}},
+			"config: 2 loader(s) failed: cannot load config.intLoader: a; cannot load config.errorLoader: b",
+		},
+		{
+			&InvalidDecodeError{nil},
+			"config: decode into nil",
+		},
+		{
+			&InvalidDecodeError{reflect.TypeOf("")},
+			"config: decode into non-pointer string",
+		},
+		{
+			&InvalidDecodeError{reflect.TypeOf((*int)(nil))},
+			"config: decode into nil *int",
+		},
+	}
+	for _, test := range tests {
+		if result := test.err.Error(); result != test.result {
+			t.Errorf("err.Error() = %v WANT %v", result, test.result)
+		}
+	}
+}
+
+func TestTypeError_Unwrap(t *testing.T) {
+	inner := errors.New("inner")
+	err := &TypeError{Err: inner}
+	if err.Unwrap() != inner {
+		t.Fail()
+	}
+}
+
+func TestValidationError_Unwrap(t *testing.T) {
+	inner := &UnknownKeyError{NewKey("a")}
+	err := &ValidationError{Errors: []error{inner}}
+	if errs := err.Unwrap(); len(errs) != 1 || errs[0] != inner {
+		t.Fail()
+	}
+}
+
+func TestLoadError_Unwrap(t *testing.T) {
+	inner := errors.New("inner")
+	err := &LoadError{Err: inner}
+	if err.Unwrap() != inner {
+		t.Fail()
+	}
+}
+
+func TestLoadErrors_Unwrap(t *testing.T) {
+	inner := &LoadError{Err: errors.New("inner")}
+	err := &LoadErrors{Errors: []*LoadError{inner}}
+	if errs := err.Unwrap(); len(errs) != 1 || errs[0] != inner {
+		t.Fail()
+	}
+	if !errors.Is(err, inner.Err) {
+		t.Error("errors.Is() should find inner.Err")
+	}
+}
@@ -1,3 +1,8 @@
 module github.com/gogolfing/config
 
-go 1.12
+go 1.20
+
+require (
+	github.com/BurntSushi/toml v1.3.2
+	gopkg.in/yaml.v3 v3.0.1
+)
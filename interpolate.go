@@ -0,0 +1,304 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+//Errors set as InterpolationError.Err.
+var (
+	//ErrInterpolationCycle means a reference, directly or indirectly, refers back
+	//to the value that contains it.
+	ErrInterpolationCycle = errors.New("reference cycle")
+
+	//ErrUnterminatedReference means a "${" is not followed by a closing "}".
+	ErrUnterminatedReference = errors.New("unterminated reference")
+
+	//ErrEnvNotSet means an "${env:NAME}" reference names an unset environment variable.
+	ErrEnvNotSet = errors.New("environment variable not set")
+)
+
+//InterpolationError describes a reference within the string value at Key that
+//could not be resolved.
+type InterpolationError struct {
+	//Key is the full Key of the value that contains Reference.
+	Key Key
+
+	//Reference is the unresolved reference without the surrounding "${" and "}".
+	Reference string
+
+	//Err is the reason Reference could not be resolved.
+	//It is one of the Err* variables in this package, a *KeyNotFoundError,
+	//or a *TypeError if Reference refers to a subtree.
+	Err error
+}
+
+func (e *InterpolationError) Error() string {
+	return fmt.Sprintf("config: cannot interpolate ${%v} at key %v: %v", e.Reference, e.Key, e.Err)
+}
+
+//Unwrap returns e.Err.
+func (e *InterpolationError) Unwrap() error {
+	return e.Err
+}
+
+//envPrefix is the prefix of references to environment variables.
+const envPrefix = "env:"
+
+//Interpolator resolves references within string values.
+//
+//A reference of the form "${some.key}" is replaced by the value stored at the
+//Key parsed from "some.key", and "${env:NAME}" is replaced by the environment
+//variable NAME.
+//Referenced values are interpolated themselves before being used.
+//A string value that is exactly one reference is replaced by the referenced value
+//itself, keeping its type, while references embedded within a longer string are
+//formatted with fmt.Sprint().
+//"$${" is an escaped "${" and is replaced by a literal "${".
+//
+//The zero value for Interpolator is ready to use.
+type Interpolator struct {
+	//KeyParser parses references into Keys.
+	//If it is nil, then PeriodSeparatorKeyParser is used by Interpolate() and the
+	//Config's KeyParser is used by a Config.
+	KeyParser KeyParser
+
+	//LookupEnv returns the value of an environment variable and whether or not it
+	//is set. If it is nil, then os.LookupEnv is used.
+	LookupEnv func(name string) (string, bool)
+}
+
+//Interpolate resolves the references in all string values in v, replacing each
+//value with its interpolated result.
+//If any reference cannot be resolved, then an *InterpolationError is returned
+//and v is unchanged.
+func (i *Interpolator) Interpolate(v *Values) error {
+	v.lock.Lock()
+	defer v.unlock()
+
+	_, err := i.interpolate(PeriodSeparatorKeyParser, v)
+	return err
+}
+
+//interpolate is Interpolate() without locking v and returns whether or not
+//any value changed.
+//keyParser is used if i.KeyParser is nil.
+func (i *Interpolator) interpolate(keyParser KeyParser, v *Values) (bool, error) {
+	keys := []Key{}
+	v.root.eachKeyValue(nil, func(key Key, value interface{}) {
+		keys = append(keys, key)
+	})
+
+	in := i.newInterpolation(keyParser, v.root, keys)
+	results, err := in.resolveAll(keys)
+	if err != nil {
+		return false, err
+	}
+	return setResults(v, keys, results), nil
+}
+
+//interpolateInto resolves the references in all string values in temp as if
+//temp were merged into base, so that references may refer to values in either.
+//Only the values in temp are interpolated. base is only read, and the caller is
+//responsible for synchronizing access to it.
+//If any reference cannot be resolved, then an *InterpolationError is returned
+//and temp is unchanged.
+func (i *Interpolator) interpolateInto(keyParser KeyParser, base *node, temp *Values) error {
+	merged := newValues(base)
+	merged.merge(nil, temp)
+
+	keys := []Key{}
+	temp.EachKeyValue(func(key Key, value interface{}) {
+		keys = append(keys, key)
+	})
+
+	in := i.newInterpolation(keyParser, merged.root, keys)
+	results, err := in.resolveAll(keys)
+	if err != nil {
+		return err
+	}
+
+	temp.lock.Lock()
+	defer temp.unlock()
+
+	setResults(temp, keys, results)
+	return nil
+}
+
+//setResults sets the value stored at keys[i] within v to results[i], keeping
+//its source, and returns whether or not any value changed.
+//v.lock must be held by the caller for writing.
+func setResults(v *Values, keys []Key, results []interface{}) bool {
+	changed := false
+	m := v.mutation()
+	for index, key := range keys {
+		s, ok := v.root.descendent(key).value.(string)
+		if ok && results[index] != s {
+			m.writableDescendent(v, key).value = results[index]
+			changed = true
+		}
+	}
+	return changed
+}
+
+//newInterpolation returns an interpolation of the values stored at pending
+//within root.
+//keyParser is used if i.KeyParser is nil.
+func (i *Interpolator) newInterpolation(keyParser KeyParser, root *node, pending []Key) *interpolation {
+	if i.KeyParser != nil {
+		keyParser = i.KeyParser
+	}
+	lookupEnv := i.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	in := &interpolation{
+		keyParser: keyParser,
+		lookupEnv: lookupEnv,
+		root:      root,
+		pending:   map[string]bool{},
+		resolved:  map[string]interface{}{},
+		resolving: map[string]bool{},
+	}
+	for _, key := range pending {
+		in.pending[keyID(key)] = true
+	}
+	return in
+}
+
+//interpolation holds the state of a single interpolation pass over a tree.
+type interpolation struct {
+	keyParser KeyParser
+	lookupEnv func(name string) (string, bool)
+
+	//root is the tree in which references are looked up.
+	root *node
+
+	//pending holds the ids of the Keys whose values are interpolated.
+	//References to all other values use the raw value.
+	pending map[string]bool
+
+	//resolved caches the interpolated values of pending Keys.
+	resolved map[string]interface{}
+
+	//resolving holds the ids of the Keys currently being interpolated and is
+	//used to detect cycles.
+	resolving map[string]bool
+}
+
+//keyID returns a string that uniquely identifies key.
+func keyID(key Key) string {
+	return strings.Join(key, "\x00")
+}
+
+//resolveAll returns the interpolated values stored at keys in the same order.
+func (in *interpolation) resolveAll(keys []Key) ([]interface{}, error) {
+	results := make([]interface{}, len(keys))
+	for index, key := range keys {
+		result, err := in.resolve(key, in.root.descendent(key).value)
+		if err != nil {
+			return nil, err
+		}
+		results[index] = result
+	}
+	return results, nil
+}
+
+//resolve returns the interpolated value, stored at key, of value.
+func (in *interpolation) resolve(key Key, value interface{}) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok || !strings.Contains(s, "${") {
+		return value, nil
+	}
+	id := keyID(key)
+	if result, ok := in.resolved[id]; ok {
+		return result, nil
+	}
+
+	in.resolving[id] = true
+	result, err := in.expand(key, s)
+	delete(in.resolving, id)
+	if err != nil {
+		return nil, err
+	}
+
+	in.resolved[id] = result
+	return result, nil
+}
+
+//expand replaces all references and escapes within s, which is stored at key.
+func (in *interpolation) expand(key Key, s string) (interface{}, error) {
+	var result strings.Builder
+	whole := true
+	for {
+		index := strings.Index(s, "${")
+		if index < 0 {
+			result.WriteString(s)
+			break
+		}
+		if index > 0 && s[index-1] == '$' {
+			result.WriteString(s[:index-1])
+			result.WriteString("${")
+			s = s[index+2:]
+			whole = false
+			continue
+		}
+		result.WriteString(s[:index])
+
+		end := strings.IndexByte(s[index:], '}')
+		if end < 0 {
+			return nil, &InterpolationError{Key: key, Reference: s[index+2:], Err: ErrUnterminatedReference}
+		}
+		end += index
+		reference := s[index+2 : end]
+
+		value, err := in.lookup(key, reference)
+		if err != nil {
+			return nil, err
+		}
+		if whole && index == 0 && end == len(s)-1 {
+			return value, nil
+		}
+		fmt.Fprint(&result, value)
+		s = s[end+1:]
+		whole = false
+	}
+	return result.String(), nil
+}
+
+//lookup returns the interpolated value of reference found within the value
+//stored at key.
+func (in *interpolation) lookup(key Key, reference string) (interface{}, error) {
+	newError := func(err error) error {
+		return &InterpolationError{Key: key, Reference: reference, Err: err}
+	}
+
+	if strings.HasPrefix(reference, envPrefix) {
+		value, ok := in.lookupEnv(strings.TrimPrefix(reference, envPrefix))
+		if !ok {
+			return nil, newError(ErrEnvNotSet)
+		}
+		return value, nil
+	}
+
+	referenceKey := in.keyParser.Parse(reference)
+	found := in.root.descendent(referenceKey)
+	if found == nil {
+		return nil, newError(&KeyNotFoundError{referenceKey})
+	}
+	if !found.isSet() {
+		return nil, newError(&TypeError{Key: referenceKey, Want: reflect.TypeOf(""), Got: valuesType})
+	}
+
+	id := keyID(referenceKey)
+	if !in.pending[id] {
+		return found.value, nil
+	}
+	if in.resolving[id] {
+		return nil, newError(ErrInterpolationCycle)
+	}
+	return in.resolve(referenceKey, found.value)
+}
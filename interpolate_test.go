@@ -0,0 +1,180 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInterpolator_Interpolate(t *testing.T) {
+	v := newLayerValues(
+		"base", "https://example.com",
+		"api", "${base}/api",
+		"users", "${api}/users",
+		"port", 8080,
+		"address", "localhost:${port}",
+		"portCopy", "${port}",
+		"home", "${env:HOME}",
+		"escaped", "$${base} and $${env:HOME}",
+		"plain", "no references",
+		"dollar", "$5",
+	)
+	i := &Interpolator{
+		LookupEnv: func(name string) (string, bool) {
+			if name == "HOME" {
+				return "/home/gopher", true
+			}
+			return "", false
+		},
+	}
+
+	if err := i.Interpolate(v); err != nil {
+		t.Fatal(err)
+	}
+
+	want := newLayerValues(
+		"base", "https://example.com",
+		"api", "https://example.com/api",
+		"users", "https://example.com/api/users",
+		"port", 8080,
+		"address", "localhost:8080",
+		"portCopy", 8080,
+		"home", "/home/gopher",
+		"escaped", "${base} and ${env:HOME}",
+		"plain", "no references",
+		"dollar", "$5",
+	)
+	if !v.Equal(want) {
+		t.Errorf("v = %v WANT %v", v.root.interfaceValue(), want.root.interfaceValue())
+	}
+}
+
+func TestInterpolator_Interpolate_keepsSources(t *testing.T) {
+	v := NewValues()
+	v.PutSource(NewKey("a"), "a", "file a")
+	v.PutSource(NewKey("b"), "${a}", "file b")
+
+	if err := (&Interpolator{}).Interpolate(v); err != nil {
+		t.Fatal(err)
+	}
+	if b := v.Get(NewKey("b")); b != "a" {
+		t.Errorf("b = %v WANT a", b)
+	}
+	if source, _ := v.Source(NewKey("b")); source != "file b" {
+		t.Errorf("source = %q WANT file b", source)
+	}
+}
+
+func TestInterpolator_Interpolate_errors(t *testing.T) {
+	lookupEnv := func(name string) (string, bool) { return "", false }
+
+	tests := []struct {
+		values    *Values
+		key       Key
+		reference string
+		err       error
+	}{
+		{newLayerValues("a", "${b}", "b", "${a}"), nil, "", ErrInterpolationCycle},
+		{newLayerValues("a", "x${a}"), NewKey("a"), "a", ErrInterpolationCycle},
+		{newLayerValues("a", "${missing}"), NewKey("a"), "missing", &KeyNotFoundError{NewKey("missing")}},
+		{newLayerValues("a", "${env:MISSING}"), NewKey("a"), "env:MISSING", ErrEnvNotSet},
+		{newLayerValues("a", "${b"), NewKey("a"), "b", ErrUnterminatedReference},
+		{newLayerValues("a", "${b}", "b.c", 1), NewKey("a"), "b", nil},
+	}
+
+	for i, test := range tests {
+		before := NewValues()
+		before.merge(nil, test.values)
+
+		err := (&Interpolator{LookupEnv: lookupEnv}).Interpolate(test.values)
+
+		interpolationErr, ok := err.(*InterpolationError)
+		if !ok {
+			t.Errorf("%v: err = %v WANT *InterpolationError", i, err)
+			continue
+		}
+		if test.key != nil && (!interpolationErr.Key.Equal(test.key) || interpolationErr.Reference != test.reference) {
+			t.Errorf("%v: err = %v WANT key %v reference %v", i, err, test.key, test.reference)
+		}
+		if test.err != nil && !reflect.DeepEqual(errors.Unwrap(err), test.err) {
+			t.Errorf("%v: errors.Unwrap(err) = %v WANT %v", i, errors.Unwrap(err), test.err)
+		}
+		if !test.values.Equal(before) {
+			t.Errorf("%v: values should be unchanged on error", i)
+		}
+	}
+}
+
+func TestInterpolator_Interpolate_keyParser(t *testing.T) {
+	v := newLayerValues("a.b", "value", "c", "${a/b}")
+
+	if err := (&Interpolator{KeyParser: SeparatorKeyParser("/")}).Interpolate(v); err != nil {
+		t.Fatal(err)
+	}
+	if c := v.Get(NewKey("c")); c != "value" {
+		t.Errorf("c = %v WANT value", c)
+	}
+}
+
+func TestInterpolationError_Error(t *testing.T) {
+	err := &InterpolationError{Key: NewKey("a"), Reference: "b", Err: ErrInterpolationCycle}
+	want := "config: cannot interpolate ${b} at key [a]: reference cycle"
+	if err.Error() != want {
+		t.Errorf("err.Error() = %q WANT %q", err.Error(), want)
+	}
+}
+
+func TestConfig_MergeLoaders_interpolation(t *testing.T) {
+	c := New()
+	c.Interpolator = &Interpolator{}
+	c.Put("base", "https://example.com")
+
+	_, err := c.MergeLoaders(&valuesLoader{newLayerValues("api", "${base}/api", "docs", "${api}/docs")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs := c.GetString("docs"); docs != "https://example.com/api/docs" {
+		t.Errorf("c.GetString(docs) = %q", docs)
+	}
+
+	_, err = c.MergeLoaders(&valuesLoader{newLayerValues("bad", "${missing}")})
+	if _, ok := err.(*InterpolationError); !ok {
+		t.Errorf("err = %v WANT *InterpolationError", err)
+	}
+	if _, ok := c.GetOk("bad"); ok {
+		t.Error("c should be unchanged on an interpolation error")
+	}
+}
+
+func TestConfig_LoadLayer_interpolation(t *testing.T) {
+	c := New()
+	c.Interpolator = &Interpolator{}
+	c.SetLayer("defaults", DefaultsPriority, newLayerValues("dir", "/var/app"))
+
+	_, err := c.LoadLayer("file", FilePriority, &valuesLoader{newLayerValues("logs", "${dir}/logs")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logs := c.GetString("logs"); logs != "/var/app/logs" {
+		t.Errorf("c.GetString(logs) = %q", logs)
+	}
+}
+
+func TestConfig_Interpolate(t *testing.T) {
+	c := New()
+	c.Put("a", "a")
+	c.Put("b", "${a}")
+
+	var watched []Change
+	c.Watch("", func(change Change) { watched = append(watched, change) })
+
+	if err := c.Interpolate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Kind: ChangeModified, Key: NewKey("b"), Old: "${a}", New: "a"},
+	}
+	if !reflect.DeepEqual(watched, want) {
+		t.Errorf("watched = %v WANT %v", watched, want)
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"sync"
+)
+
+//MarshalJSON implements encoding/json.Marshaler.
+//Lists (see v.Len()) are encoded as JSON arrays, other subtrees are encoded
+//as JSON objects, with object keys in sorted order, and set values are encoded
+//with encoding/json.Marshal().
+//Sensitive values are NOT redacted. Use v.Redacted() for output that should be.
+func (v *Values) MarshalJSON() ([]byte, error) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	return json.Marshal(v.root.interfaceValue())
+}
+
+//UnmarshalJSON implements encoding/json.Unmarshaler.
+//All associations in v are replaced by those in data.
+//JSON objects are stored as subtrees, non empty JSON arrays are stored as lists
+//(see v.PutList()) with empty objects as empty elements, JSON numbers are stored
+//as int64s if they are integers that fit and float64s otherwise, and all other
+//JSON values are stored as they are decoded by encoding/json into an interface{}.
+//Empty JSON objects are not stored, and empty JSON arrays are stored as empty
+//[]interface{}s.
+//A data that is not a JSON object is stored at the empty Key.
+//A JSON null leaves v unchanged.
+//
+//UnmarshalJSON may be called on a zero Values.
+func (v *Values) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return err
+	}
+	if decoded == nil {
+		return nil
+	}
+
+	if v.lock == nil {
+		v.lock = &sync.RWMutex{}
+	}
+	v.lock.Lock()
+	defer v.unlock()
+
+	m := v.mutation()
+	v.root = newNode()
+	putJSON(m.writableRoot(v), nil, decoded, m)
+	return nil
+}
+
+//putJSON puts the JSON decoded value into n at key, expanding objects and
+//arrays into subtrees as the loaders/json package does.
+func putJSON(n *node, key Key, value interface{}, m mutation) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for keyPart, child := range value {
+			putJSON(n, key.AppendStrings(keyPart), child, m)
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			n.put(key, value, "", m)
+			return
+		}
+		n.putList(key, len(value), m)
+		for i, child := range value {
+			putJSON(n, key.AppendStrings(strconv.Itoa(i)), child, m)
+		}
+	default:
+		n.put(key, jsonValue(value), "", m)
+	}
+}
+
+//jsonValue converts value into an int64 or float64 if it is a json.Number.
+func jsonValue(value interface{}) interface{} {
+	if number, ok := value.(json.Number); ok {
+		if i, err := number.Int64(); err == nil {
+			return i
+		}
+		f, _ := number.Float64()
+		return f
+	}
+	return value
+}
+
+//MarshalJSON implements encoding/json.Marshaler via c.Values().MarshalJSON().
+func (c *Config) MarshalJSON() ([]byte, error) {
+	return c.values.MarshalJSON()
+}
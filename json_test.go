@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValues_MarshalJSON(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("b", "d"), 1)
+	v.Put(NewKey("b", "c"), "c")
+	v.Put(NewKey("a"), nil)
+	v.Put(NewKey("password"), "secret")
+	v.MarkSensitive(NewKey("password"))
+
+	out, err := json.Marshal(v)
+
+	want := `{"a":null,"b":{"c":"c","d":1},"password":"secret"}`
+	if string(out) != want || err != nil {
+		t.Errorf("json.Marshal(v) = %s, %v WANT %v", out, err, want)
+	}
+
+	out, _ = json.Marshal(v.Redacted())
+	want = `{"a":null,"b":{"c":"c","d":1},"password":"[REDACTED]"}`
+	if string(out) != want {
+		t.Errorf("json.Marshal(v.Redacted()) = %s WANT %v", out, want)
+	}
+}
+
+func TestValues_MarshalJSON_empty(t *testing.T) {
+	out, err := json.Marshal(NewValues())
+	if string(out) != "{}" || err != nil {
+		t.Errorf("json.Marshal(NewValues()) = %s, %v", out, err)
+	}
+}
+
+func TestValues_UnmarshalJSON(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("old"), "old")
+
+	err := json.Unmarshal([]byte(`{"a": {"b": 1, "c": 1.5, "e": {}}, "d": [1, "x", {"y": 2}], "n": null}`), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key   Key
+		value interface{}
+		ok    bool
+	}{
+		{NewKey("old"), nil, false},
+		{NewKey("a", "b"), int64(1), true},
+		{NewKey("a", "c"), 1.5, true},
+		{NewKey("a", "e"), nil, false},
+		{NewKey("n"), nil, true},
+		{NewKey("d", "0"), int64(1), true},
+		{NewKey("d", "1"), "x", true},
+		{NewKey("d", "2", "y"), int64(2), true},
+	}
+	for _, test := range tests {
+		value, ok := v.GetOk(test.key)
+		if value != test.value || ok != test.ok {
+			t.Errorf("v.GetOk(%v) = %v, %v WANT %v, %v", test.key, value, ok, test.value, test.ok)
+		}
+	}
+
+	if length := v.Len(NewKey("d")); length != 3 {
+		t.Errorf("v.Len(d) = %v WANT 3", length)
+	}
+}
+
+func TestValues_UnmarshalJSON_emptyArray(t *testing.T) {
+	v := NewValues()
+	if err := json.Unmarshal([]byte(`{"a": []}`), v); err != nil {
+		t.Fatal(err)
+	}
+	a, ok := v.GetSliceOk(NewKey("a"))
+	if !ok || a == nil || len(a) != 0 {
+		t.Errorf("v.GetSliceOk(a) = %#v, %v WANT empty slice", a, ok)
+	}
+
+	out, _ := json.Marshal(v)
+	if string(out) != `{"a":[]}` {
+		t.Errorf("json.Marshal(v) = %s", out)
+	}
+}
+
+func TestValues_UnmarshalJSON_emptyElements(t *testing.T) {
+	in := `{"a":[{},{"b":1}]}`
+	v := NewValues()
+	if err := json.Unmarshal([]byte(in), v); err != nil {
+		t.Fatal(err)
+	}
+	if length := v.Len(NewKey("a")); length != 2 {
+		t.Errorf("v.Len(a) = %v WANT 2", length)
+	}
+
+	out, err := json.Marshal(v)
+	if string(out) != in || err != nil {
+		t.Errorf("json.Marshal(v) = %s, %v WANT %v", out, err, in)
+	}
+}
+
+func TestValues_MarshalJSON_lists(t *testing.T) {
+	in := `{"a":[{"b":1},{"b":2}],"c":{"0":"x","2":"y"},"d":[[true],"z"]}`
+	v := NewValues()
+	if err := json.Unmarshal([]byte(in), v); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := json.Marshal(v)
+	if string(out) != in || err != nil {
+		t.Errorf("json.Marshal(v) = %s, %v WANT %v", out, err, in)
+	}
+}
+
+func TestValues_UnmarshalJSON_zero(t *testing.T) {
+	var s struct {
+		V Values
+		P *Values
+	}
+	if err := json.Unmarshal([]byte(`{"V": {"a": "a"}, "P": {"b": 2}, "X": null}`), &s); err != nil {
+		t.Fatal(err)
+	}
+	if a := s.V.Get(NewKey("a")); a != "a" {
+		t.Errorf("s.V a = %v WANT a", a)
+	}
+	if b := s.P.Get(NewKey("b")); b != int64(2) {
+		t.Errorf("s.P b = %v WANT 2", b)
+	}
+}
+
+func TestValues_UnmarshalJSON_notObject(t *testing.T) {
+	v := NewValues()
+	if err := json.Unmarshal([]byte(`"value"`), v); err != nil {
+		t.Fatal(err)
+	}
+	if value := v.Get(NewKey()); value != "value" {
+		t.Errorf("v.Get(empty) = %v WANT value", value)
+	}
+
+	if err := json.Unmarshal([]byte(`{`), v); err == nil {
+		t.Error("json.Unmarshal() should error on invalid JSON")
+	}
+}
+
+func TestConfig_MarshalJSON(t *testing.T) {
+	c := New()
+	c.Put("a.b", "b")
+
+	out, err := json.Marshal(c)
+	if string(out) != `{"a":{"b":"b"}}` || err != nil {
+		t.Errorf("json.Marshal(c) = %s, %v", out, err)
+	}
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"context"
+	"sort"
+)
+
+//Conventional layer priorities for use with Config.SetLayer() and Config.LoadLayer().
+//Layers with higher priorities override the values of layers with lower priorities.
+const (
+	DefaultsPriority = 0
+	FilePriority     = 100
+	EnvPriority      = 200
+	FlagsPriority    = 300
+	RuntimePriority  = 400
+)
+
+//RuntimeLayer is the name of the layer, with RuntimePriority, that holds the
+//values set by a layered Config's non layer methods, such as Put() and MergeLoaders().
+const RuntimeLayer = "runtime"
+
+//layer is a named and prioritized set of values within a layered Config.
+type layer struct {
+	name     string
+	priority int
+	values   *Values
+}
+
+//SetLayer replaces the values of the layer named name, creating the layer if it
+//does not exist, and returns the resulting Changes to c's values.
+//Values in layers with a higher priority override those in layers with a lower
+//priority. Layers with equal priorities are ordered by when they were created.
+//values is copied, and so later changes to it DO NOT AFFECT c.
+//Values in values without a source are given the source "layer <name>".
+//
+//The first call to SetLayer or LoadLayer makes c layered: every value already in
+//c is moved to the RuntimeLayer, and from then on Put(), Remove(), Merge(),
+//MergeLoaders(), LoadAll(), and Reload() all modify the RuntimeLayer instead
+//of c's values directly.
+//Removing a value from a higher layer therefore reveals the value from the next
+//lower layer again.
+//
+//c's values are always the resolution of all layers. The resolution is kept up
+//to date as layers change, rather than computed on each read, so that reading a
+//layered Config costs the same as reading any other Config.
+//Watchers are notified of the resulting Changes.
+//Modifications made directly to c.Values() are lost on the next resolution.
+//
+//The changed results of Put(), PutKey(), and Merge(), and the ok result of
+//Remove(), describe c's resolved values rather than the RuntimeLayer's.
+//For example, putting a runtime value equal to that of a lower layer reports no
+//change.
+func (c *Config) SetLayer(name string, priority int, values *Values) []Change {
+	changes := c.setLayer(name, priority, copyValues(values, "layer "+name))
+	c.notify(changes)
+	return changes
+}
+
+//LoadLayer is SetLayer(name, priority, values) where values are the result of
+//merging all loaders in order, as with MergeLoaders().
+//If any Loader.Load() errors, then that error is returned and c is unchanged.
+//If c.Interpolator is not nil, then the loaded values are interpolated as with
+//MergeLoaders().
+func (c *Config) LoadLayer(name string, priority int, loaders ...Loader) ([]Change, error) {
+	return c.LoadLayerContext(context.Background(), name, priority, loaders...)
+}
+
+//LoadLayerContext is c.LoadLayer() that honors ctx as c.MergeLoadersContext() does.
+func (c *Config) LoadLayerContext(ctx context.Context, name string, priority int, loaders ...Loader) ([]Change, error) {
+	temp, _, _, loadErr := c.mergeLoaders(ctx, loaders, nil)
+	if temp == nil {
+		return nil, loadErr
+	}
+	if c.Interpolator != nil {
+		c.values.lock.RLock()
+		err := c.Interpolator.interpolateInto(c.KeyParser, c.values.root, temp)
+		c.values.lock.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	changes := c.setLayer(name, priority, copyValues(temp, "layer "+name))
+	c.notify(changes)
+	return changes, loadErr
+}
+
+func (c *Config) setLayer(name string, priority int, values *Values) []Change {
+	c.layerLock.Lock()
+	defer c.layerLock.Unlock()
+
+	if !c.isLayered() {
+		c.layers = []*layer{{
+			name:     RuntimeLayer,
+			priority: RuntimePriority,
+			values:   copyValues(c.values, ""),
+		}}
+	}
+
+	if l, ok := c.findLayer(name); ok {
+		l.values = values
+		l.priority = priority
+	} else {
+		c.layers = append(c.layers, &layer{
+			name:     name,
+			priority: priority,
+			values:   values,
+		})
+	}
+	c.sortLayers()
+	return c.resolve()
+}
+
+//Layer returns a copy of the values in the layer named name, with the same
+//sensitive Key patterns as c's values.
+//ok indicates whether or not the layer exists.
+func (c *Config) Layer(name string) (values *Values, ok bool) {
+	c.layerLock.Lock()
+	defer c.layerLock.Unlock()
+
+	l, ok := c.findLayer(name)
+	if !ok {
+		return nil, false
+	}
+	values = copyValues(l.values, "")
+	c.values.lock.RLock()
+	values.sensitive = c.values.sensitive.rebase(nil)
+	c.values.lock.RUnlock()
+	return values, true
+}
+
+//LayerNames returns the names of all layers in c in ascending priority order.
+//It is empty if c is not layered.
+func (c *Config) LayerNames() []string {
+	c.layerLock.Lock()
+	defer c.layerLock.Unlock()
+
+	names := make([]string, 0, len(c.layers))
+	for _, l := range c.layers {
+		names = append(names, l.name)
+	}
+	return names
+}
+
+//RemoveLayer removes the layer named name from c and returns the resulting
+//Changes to c's values.
+//c remains layered even if all layers are removed.
+//Removing the RuntimeLayer discards all values set by c's non layer methods,
+//and the RuntimeLayer is recreated on the next modification.
+func (c *Config) RemoveLayer(name string) []Change {
+	c.layerLock.Lock()
+	changes := []Change(nil)
+	for i, l := range c.layers {
+		if l.name == name {
+			c.layers = append(c.layers[:i:i], c.layers[i+1:]...)
+			changes = c.resolve()
+			break
+		}
+	}
+	c.layerLock.Unlock()
+
+	c.notify(changes)
+	return changes
+}
+
+//findLayer returns the layer named name.
+//c.layerLock must be held by the caller.
+func (c *Config) findLayer(name string) (*layer, bool) {
+	for _, l := range c.layers {
+		if l.name == name {
+			return l, true
+		}
+	}
+	return nil, false
+}
+
+//isLayered determines whether or not c is layered.
+//c.layerLock must be held by the caller.
+func (c *Config) isLayered() bool {
+	return c.layers != nil
+}
+
+//ownValues returns the Values modified by c's non layer methods.
+//This is c.values if c is not layered and the RuntimeLayer's values otherwise.
+//The RuntimeLayer is created if it does not exist.
+//c.layerLock must be held by the caller.
+func (c *Config) ownValues() *Values {
+	if !c.isLayered() {
+		return c.values
+	}
+	if l, ok := c.findLayer(RuntimeLayer); ok {
+		return l.values
+	}
+	l := &layer{
+		name:     RuntimeLayer,
+		priority: RuntimePriority,
+		values:   NewValues(),
+	}
+	c.layers = append(c.layers, l)
+	c.sortLayers()
+	return l.values
+}
+
+//sortLayers stably sorts c's layers in ascending priority order.
+//c.layerLock must be held by the caller.
+func (c *Config) sortLayers() {
+	sort.SliceStable(c.layers, func(i, j int) bool {
+		return c.layers[i].priority < c.layers[j].priority
+	})
+}
+
+//cloneLayers returns deep copies of c's layers.
+//c.layerLock must be held by the caller.
+func (c *Config) cloneLayers() []*layer {
+	if !c.isLayered() {
+		return nil
+	}
+	result := make([]*layer, 0, len(c.layers))
+	for _, l := range c.layers {
+		result = append(result, &layer{
+			name:     l.name,
+			priority: l.priority,
+			values:   copyValues(l.values, ""),
+		})
+	}
+	return result
+}
+
+//copyValues returns a new Values with all associations in values, where values
+//without a source are given source.
+func copyValues(values *Values, source string) *Values {
+	result := NewValues()
+	result.mergeSource(nil, values, source)
+	return result
+}
+
+//resolve merges all layers in priority order into c's values and returns the
+//resulting Changes.
+//c.layerLock must be held by the caller.
+func (c *Config) resolve() []Change {
+	resolved := NewValues()
+	for _, l := range c.layers {
+		resolved.merge(nil, l.values)
+	}
+	return c.values.replace(resolved)
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newLayerValues(keyValues ...interface{}) *Values {
+	v := NewValues()
+	for i := 0; i < len(keyValues); i += 2 {
+		v.Put(PeriodSeparatorKeyParser.Parse(keyValues[i].(string)), keyValues[i+1])
+	}
+	return v
+}
+
+func TestConfig_SetLayer(t *testing.T) {
+	c := New()
+	c.Put("existing", 1)
+
+	c.SetLayer("defaults", DefaultsPriority, newLayerValues("a", "default", "b", "default"))
+	c.SetLayer("flags", FlagsPriority, newLayerValues("a", "flag"))
+	changes := c.SetLayer("file", FilePriority, newLayerValues("a", "file", "b", "file"))
+
+	want := []Change{
+		{Kind: ChangeModified, Key: NewKey("b"), Old: "default", New: "file"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %v WANT %v", changes, want)
+	}
+
+	if names := c.LayerNames(); !reflect.DeepEqual(names, []string{"defaults", "file", "flags", RuntimeLayer}) {
+		t.Errorf("c.LayerNames() = %v", names)
+	}
+
+	wantValues := newLayerValues("a", "flag", "b", "file", "existing", 1)
+	if !c.Values().Equal(wantValues) {
+		t.Errorf("c.Values() = %v WANT %v", c.Values(), wantValues)
+	}
+
+	if source, _ := c.Source("a"); source != "layer flags" {
+		t.Errorf("c.Source(a) = %q", source)
+	}
+}
+
+func TestConfig_SetLayer_copiesValues(t *testing.T) {
+	c := New()
+	values := newLayerValues("a", 1)
+	c.SetLayer("defaults", DefaultsPriority, values)
+
+	values.Put(NewKey("a"), 2)
+	if a := c.Get("a"); a != 1 {
+		t.Errorf("c.Get(a) = %v WANT 1", a)
+	}
+
+	layer, _ := c.Layer("defaults")
+	layer.Put(NewKey("a"), 3)
+	if a := c.Get("a"); a != 1 {
+		t.Errorf("c.Get(a) = %v WANT 1", a)
+	}
+}
+
+func TestConfig_RemoveLayer(t *testing.T) {
+	c := New()
+	c.SetLayer("defaults", DefaultsPriority, newLayerValues("a", "default"))
+	c.SetLayer("env", EnvPriority, newLayerValues("a", "env"))
+
+	var watched []Change
+	c.Watch("a", func(change Change) { watched = append(watched, change) })
+
+	changes := c.RemoveLayer("env")
+	want := []Change{
+		{Kind: ChangeModified, Key: NewKey("a"), Old: "env", New: "default"},
+	}
+	if !reflect.DeepEqual(changes, want) || !reflect.DeepEqual(watched, want) {
+		t.Errorf("c.RemoveLayer() = %v, watched %v WANT %v", changes, watched, want)
+	}
+
+	if changes := c.RemoveLayer("env"); changes != nil {
+		t.Errorf("c.RemoveLayer() of a missing layer = %v", changes)
+	}
+	if _, ok := c.Layer("env"); ok {
+		t.Error("c.Layer() of a removed layer should not be ok")
+	}
+}
+
+func TestConfig_layered_runtimeOverride(t *testing.T) {
+	c := New()
+	c.SetLayer("defaults", DefaultsPriority, newLayerValues("a", "default"))
+
+	c.Put("a", "runtime")
+	if a := c.GetString("a"); a != "runtime" {
+		t.Errorf("c.GetString(a) = %q WANT runtime", a)
+	}
+
+	defaults, _ := c.Layer("defaults")
+	if a := defaults.Get(NewKey("a")); a != "default" {
+		t.Errorf("defaults layer a = %v WANT default", a)
+	}
+
+	value, ok := c.Remove("a")
+	if value != "runtime" || !ok {
+		t.Errorf("c.Remove(a) = %v, %v", value, ok)
+	}
+	if a := c.GetString("a"); a != "default" {
+		t.Errorf("c.GetString(a) = %q WANT default", a)
+	}
+}
+
+func TestConfig_layered_effectiveResults(t *testing.T) {
+	c := New()
+	c.SetLayer("defaults", DefaultsPriority, newLayerValues("a", "default", "b", "default"))
+	c.SetLayer("flags", FlagsPriority, newLayerValues("b", "flag"))
+
+	if changed := c.Put("a", "default"); changed {
+		t.Error("c.Put(a) of the default value should not change c")
+	}
+	runtime, _ := c.Layer(RuntimeLayer)
+	if a := runtime.Get(NewKey("a")); a != "default" {
+		t.Errorf("runtime layer a = %v WANT default", a)
+	}
+	if value, ok := c.Remove("a"); ok || value != nil {
+		t.Errorf("c.Remove(a) = %v, %v WANT <nil>, false", value, ok)
+	}
+	if a := c.GetString("a"); a != "default" {
+		t.Errorf("c.GetString(a) = %q WANT default", a)
+	}
+
+	if changed := c.Put("b", "runtime"); !changed {
+		t.Error("c.Put(b) over a lower layer should change c")
+	}
+	c.SetLayer("override", RuntimePriority+1, newLayerValues("b", "override"))
+	if value, ok := c.Remove("b"); ok || value != nil {
+		t.Errorf("c.Remove(b) hidden by override = %v, %v WANT <nil>, false", value, ok)
+	}
+	if _, ok := c.Remove("b"); ok {
+		t.Error("c.Remove(b) of a missing runtime value should not be ok")
+	}
+	if changed := c.Put("c", "runtime"); !changed {
+		t.Error("c.Put(c) should change c")
+	}
+	if value, ok := c.Remove("c"); !ok || value != "runtime" {
+		t.Errorf("c.Remove(c) = %v, %v WANT runtime, true", value, ok)
+	}
+}
+
+func TestConfig_LoadLayer(t *testing.T) {
+	c := New()
+	c.Put("a", "runtime")
+
+	changes, err := c.LoadLayer("file", FilePriority, &valuesLoader{newLayerValues("a", "file", "b", "file")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Kind: ChangeAdded, Key: NewKey("b"), New: "file"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("c.LoadLayer() = %v WANT %v", changes, want)
+	}
+	if source, _ := c.Source("b"); source != "*config.valuesLoader" {
+		t.Errorf("c.Source(b) = %q", source)
+	}
+
+	changes, err = c.LoadLayer("file", FilePriority, errorLoader("load"))
+	if changes != nil || err == nil || err.Error() != "config: cannot load config.errorLoader: load" {
+		t.Errorf("c.LoadLayer() = %v, %v WANT load error", changes, err)
+	}
+	if b := c.Get("b"); b != "file" {
+		t.Errorf("c.Get(b) = %v WANT file", b)
+	}
+}
+
+func TestConfig_Clone_layered(t *testing.T) {
+	c := New()
+	c.SetLayer("defaults", DefaultsPriority, newLayerValues("a", "default"))
+
+	clone := c.Clone()
+	clone.SetLayer("defaults", DefaultsPriority, newLayerValues("a", "clone"))
+
+	if a := c.Get("a"); a != "default" {
+		t.Errorf("c.Get(a) = %v WANT default", a)
+	}
+	if a := clone.Get("a"); a != "clone" {
+		t.Errorf("clone.Get(a) = %v WANT clone", a)
+	}
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"reflect"
+	"strconv"
+)
+
+//Len returns the number of elements in the list stored at key, or 0 if there
+//is no list stored at key.
+//
+//A list is either a subtree whose Key parts are exactly the indices "0", "1",
+//..., "n-1" for some n > 0, a subtree put with v.PutList(), or a set value that
+//is a slice or array.
+//Loaders such as loaders/json store lists with v.PutList() so that each element
+//is reachable by Key, e.g. [servers 0 host].
+func (v *Values) Len(key Key) int {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	found := v.root.descendent(key)
+	if found == nil {
+		return 0
+	}
+	if found.isSet() {
+		rv := reflect.ValueOf(found.value)
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			return rv.Len()
+		}
+		return 0
+	}
+	length, _ := found.listLen()
+	return length
+}
+
+//GetSlice is sugar for v.GetSliceOk() that ignores the ok return value.
+func (v *Values) GetSlice(key Key) []interface{} {
+	s, _ := v.GetSliceOk(key)
+	return s
+}
+
+//GetSliceOk returns the elements of the list stored at key.
+//ok indicates whether or not a list is stored at key.
+//Elements that are subtrees are returned as *Values, as they are by v.Get().
+//Elements of slice or array values are copied into the result.
+func (v *Values) GetSliceOk(key Key) (s []interface{}, ok bool) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	found := v.root.descendent(key)
+	if found == nil {
+		return nil, false
+	}
+	if found.isSet() {
+		rv := reflect.ValueOf(found.value)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, false
+		}
+		s = make([]interface{}, rv.Len())
+		for i := range s {
+			s[i] = rv.Index(i).Interface()
+		}
+		return s, true
+	}
+	length, ok := found.listLen()
+	if !ok {
+		return nil, false
+	}
+	s = make([]interface{}, length)
+	for i := range s {
+		keyPart := strconv.Itoa(i)
+		child := found.children[keyPart]
+		if child.isSet() {
+			s[i] = child.value
+			continue
+		}
+		s[i] = v.subValues(child, key.AppendStrings(keyPart))
+	}
+	return s, true
+}
+
+//GetValuesSlice is sugar for v.GetValuesSliceOk() that ignores the ok return value.
+func (v *Values) GetValuesSlice(key Key) []*Values {
+	s, _ := v.GetValuesSliceOk(key)
+	return s
+}
+
+//GetValuesSliceOk returns the elements of the list stored at key, all of which
+//must be subtrees, as *Values.
+//ok indicates whether or not such a list is stored at key.
+func (v *Values) GetValuesSliceOk(key Key) (s []*Values, ok bool) {
+	elems, ok := v.GetSliceOk(key)
+	if !ok {
+		return nil, false
+	}
+	s = make([]*Values, len(elems))
+	for i, elem := range elems {
+		if s[i], ok = elem.(*Values); !ok {
+			return nil, false
+		}
+	}
+	return s, true
+}
+
+//listLen returns the number of children of n and whether or not n is a list,
+//i.e. n's children are keyed by exactly the indices "0" through "n-1", or n is
+//an empty list put with Values.PutList().
+func (n *node) listLen() (int, bool) {
+	if n.isSet() {
+		return 0, false
+	}
+	if len(n.children) == 0 {
+		return 0, n.list
+	}
+	for keyPart := range n.children {
+		if index, ok := listIndex(keyPart); !ok || index >= len(n.children) {
+			return 0, false
+		}
+	}
+	return len(n.children), true
+}
+
+//listIndex returns keyPart as a list index and whether or not it is one, i.e.
+//keyPart is a non negative integer in its canonical form.
+func listIndex(keyPart string) (int, bool) {
+	index, err := strconv.Atoi(keyPart)
+	if err != nil || index < 0 || strconv.Itoa(index) != keyPart {
+		return 0, false
+	}
+	return index, true
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newListValues() *Values {
+	v := NewValues()
+	v.Put(NewKey("servers", "0", "host"), "a")
+	v.Put(NewKey("servers", "1", "host"), "b")
+	v.Put(NewKey("ports", "0"), 80)
+	v.Put(NewKey("ports", "1"), 443)
+	v.Put(NewKey("mixed", "0"), "x")
+	v.Put(NewKey("mixed", "1", "y"), "y")
+	v.Put(NewKey("sparse", "0"), 0)
+	v.Put(NewKey("sparse", "2"), 2)
+	v.Put(NewKey("padded", "00"), 0)
+	v.Put(NewKey("raw"), []string{"a", "b", "c"})
+	v.Put(NewKey("empty"), []interface{}{})
+	v.Put(NewKey("scalar"), "scalar")
+	return v
+}
+
+func TestValues_Len(t *testing.T) {
+	v := newListValues()
+
+	tests := []struct {
+		key    Key
+		length int
+	}{
+		{NewKey("servers"), 2},
+		{NewKey("ports"), 2},
+		{NewKey("mixed"), 2},
+		{NewKey("sparse"), 0},
+		{NewKey("padded"), 0},
+		{NewKey("raw"), 3},
+		{NewKey("empty"), 0},
+		{NewKey("scalar"), 0},
+		{NewKey("missing"), 0},
+		{NewKey("servers", "0"), 0},
+	}
+
+	for _, test := range tests {
+		if length := v.Len(test.key); length != test.length {
+			t.Errorf("v.Len(%v) = %v WANT %v", test.key, length, test.length)
+		}
+	}
+}
+
+func TestValues_GetSliceOk(t *testing.T) {
+	v := newListValues()
+
+	tests := []struct {
+		key Key
+		s   []interface{}
+		ok  bool
+	}{
+		{NewKey("ports"), []interface{}{80, 443}, true},
+		{NewKey("raw"), []interface{}{"a", "b", "c"}, true},
+		{NewKey("empty"), []interface{}{}, true},
+		{NewKey("sparse"), nil, false},
+		{NewKey("scalar"), nil, false},
+		{NewKey("missing"), nil, false},
+	}
+
+	for _, test := range tests {
+		s, ok := v.GetSliceOk(test.key)
+		if !reflect.DeepEqual(s, test.s) || ok != test.ok {
+			t.Errorf("v.GetSliceOk(%v) = %v, %v WANT %v, %v", test.key, s, ok, test.s, test.ok)
+		}
+	}
+
+	mixed := v.GetSlice(NewKey("mixed"))
+	if len(mixed) != 2 || mixed[0] != "x" {
+		t.Fatalf("v.GetSlice(mixed) = %v", mixed)
+	}
+	if y := mixed[1].(*Values).Get(NewKey("y")); y != "y" {
+		t.Errorf("mixed[1] y = %v WANT y", y)
+	}
+}
+
+func TestValues_GetValuesSliceOk(t *testing.T) {
+	v := newListValues()
+	v.MarkSensitive(NewKey("servers", "*", "host"))
+
+	servers, ok := v.GetValuesSliceOk(NewKey("servers"))
+	if !ok || len(servers) != 2 {
+		t.Fatalf("v.GetValuesSliceOk(servers) = %v, %v", servers, ok)
+	}
+	for i, want := range []string{"a", "b"} {
+		if host := servers[i].Get(NewKey("host")); host != want {
+			t.Errorf("servers[%v] host = %v WANT %v", i, host, want)
+		}
+		if !servers[i].IsSensitive(NewKey("host")) {
+			t.Errorf("servers[%v] host should be sensitive", i)
+		}
+	}
+
+	servers[0].Put(NewKey("host"), "changed")
+	if host := v.Get(NewKey("servers", "0", "host")); host != "a" {
+		t.Errorf("v servers.0.host = %v WANT a", host)
+	}
+
+	for _, key := range []Key{NewKey("ports"), NewKey("mixed"), NewKey("missing")} {
+		if s, ok := v.GetValuesSliceOk(key); s != nil || ok {
+			t.Errorf("v.GetValuesSliceOk(%v) = %v, %v WANT nil, false", key, s, ok)
+		}
+	}
+}
+
+func TestValues_Decode_interfaceList(t *testing.T) {
+	v := newListValues()
+
+	var out interface{}
+	if err := v.DecodeKey(NewKey("servers"), &out); err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{
+		map[string]interface{}{"host": "a"},
+		map[string]interface{}{"host": "b"},
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("out = %v WANT %v", out, want)
+	}
+}
@@ -1,15 +1,195 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strings"
 )
 
 //Loader defines an entity that can generate a new Values instance.
+//
+//Loaders may record where each individual value came from with Values.PutSource().
+//Values without a recorded source are attributed to the Loader itself when
+//merged by Config.MergeLoaders(). See Named and Config.Source().
 type Loader interface {
 	Load() (*Values, error)
 }
 
+//ContextLoader is an optional interface that Loaders can implement to honor the
+//cancellation and deadline of a context.Context while loading, e.g. Loaders that
+//read from the network or run commands.
+//
+//Loaders that do not implement ContextLoader are still bounded by the context
+//given to Config.MergeLoadersContext() and friends, but their Load() keeps
+//running in the background after the context is done.
+type ContextLoader interface {
+	Loader
+
+	//LoadContext is Load() that returns ctx.Err(), or an error wrapping it, once
+	//ctx is done.
+	LoadContext(ctx context.Context) (*Values, error)
+}
+
+//FileLoader is an optional interface that Loaders can implement to list the
+//files that they load from, e.g. so that a Poller can reload a Config when
+//any of them changes.
+type FileLoader interface {
+	Loader
+
+	//Paths returns the paths of the files that Load() reads.
+	Paths() []string
+}
+
+//LoaderPaths returns l.Paths() if l is a FileLoader, and nil otherwise.
+func LoaderPaths(l Loader) []string {
+	if fileLoader, ok := l.(FileLoader); ok {
+		return fileLoader.Paths()
+	}
+	return nil
+}
+
+//LoadContext returns the result of l.LoadContext(ctx) if l is a ContextLoader,
+//and l.Load() otherwise.
+//It is how Config.MergeLoadersContext() and friends load each Loader, and it is
+//meant for Loaders that wrap other Loaders.
+//If ctx is done before l.Load() returns, then ctx.Err() is returned immediately
+//and l.Load() is left to finish on its own.
+func LoadContext(ctx context.Context, l Loader) (*Values, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if contextLoader, ok := l.(ContextLoader); ok {
+		return contextLoader.LoadContext(ctx)
+	}
+	if ctx.Done() == nil {
+		return l.Load()
+	}
+
+	type result struct {
+		values *Values
+		err    error
+	}
+	results := make(chan result, 1)
+	go func() {
+		values, err := l.Load()
+		results <- result{values, err}
+	}()
+	select {
+	case r := <-results:
+		return r.values, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+//LoadPolicy determines how the errors of a Loader are treated when it is merged
+//by Config.MergeLoaders() and friends. See WithPolicy().
+type LoadPolicy int
+
+const (
+	//LoadRequired treats every error of a Loader as a failure to load.
+	//It is the policy of Loaders that are not given one with WithPolicy() (see
+	//LoaderPolicy()).
+	LoadRequired LoadPolicy = iota
+
+	//LoadOptional ignores every error of a Loader, which then provides no values,
+	//except those that wrap context.Canceled or context.DeadlineExceeded.
+	LoadOptional
+
+	//LoadIgnoreMissing ignores the errors of a Loader that wrap os.ErrNotExist,
+	//e.g. from opening a file that does not exist, and treats all others as
+	//LoadRequired does.
+	LoadIgnoreMissing
+)
+
+//Ignores determines whether or not p ignores err.
+//Context errors are never ignored, since they are not the Loader's failure.
+func (p LoadPolicy) Ignores(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	switch p {
+	case LoadOptional:
+		return true
+	case LoadIgnoreMissing:
+		return errors.Is(err, os.ErrNotExist)
+	}
+	return false
+}
+
+//WithPolicy returns a Loader that loads with l and whose errors are treated
+//according to policy.
+//The result has l's name (see Named) and paths (see FileLoader), and it honors
+//contexts as l does. It is a PolicyLoader, and so Loaders that wrap it, such as
+//those of loaders/combine, may keep policy.
+func WithPolicy(l Loader, policy LoadPolicy) Loader {
+	return &policyLoader{
+		Loader: l,
+		policy: policy,
+	}
+}
+
+type policyLoader struct {
+	Loader
+	policy LoadPolicy
+}
+
+func (l *policyLoader) LoadContext(ctx context.Context) (*Values, error) {
+	return LoadContext(ctx, l.Loader)
+}
+
+//Name returns the name of l's underlying Loader.
+func (l *policyLoader) Name() string {
+	return LoaderName(l.Loader)
+}
+
+//Paths returns the paths of l's underlying Loader.
+func (l *policyLoader) Paths() []string {
+	return LoaderPaths(l.Loader)
+}
+
+//Policy returns the LoadPolicy given to WithPolicy().
+func (l *policyLoader) Policy() LoadPolicy {
+	return l.policy
+}
+
+//PolicyLoader is an optional interface that Loaders can implement to declare how
+//their errors are treated, e.g. Loaders that wrap a Loader given to WithPolicy().
+type PolicyLoader interface {
+	Loader
+
+	//Policy returns the LoadPolicy of the errors of Load().
+	Policy() LoadPolicy
+}
+
+//LoaderPolicy returns l.Policy() if l is a PolicyLoader, and LoadRequired otherwise.
+func LoaderPolicy(l Loader) LoadPolicy {
+	if policyLoader, ok := l.(PolicyLoader); ok {
+		return policyLoader.Policy()
+	}
+	return LoadRequired
+}
+
+//Named is an optional interface that Loaders can implement to describe themselves,
+//e.g. "file /etc/app.json".
+//The name is used as the source of values that a Loader does not otherwise
+//record a source for.
+type Named interface {
+	Name() string
+}
+
+//LoaderName returns l.Name() if l implements Named, and the type of l otherwise.
+//It is the source given to values that l does not record a source for.
+func LoaderName(l Loader) string {
+	if named, ok := l.(Named); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", l)
+}
+
 //ReaderFuncLoader is a func definition that takes in an io.Reader and returns
 //a new Values instance and possible error.
 type ReaderFuncLoader func(io.Reader) (*Values, error)
@@ -21,8 +201,9 @@ type fileFuncLoader struct {
 
 //NewFileFuncLoader creates a Loader that uses rfl to load and merge Values from
 //from each file existing at each path in paths.
-//If rfl returns an error for any path in paths then that error is immediately
-//returned from Loader.Load() and Values will be nil.
+//Values that rfl does not record a source for are given the source "file <path>".
+//If opening any path in paths, or rfl for it, fails then a *LoadError with that
+//Path is immediately returned from Loader.Load() and Values will be nil.
 func NewFileFuncLoader(rfl ReaderFuncLoader, paths ...string) Loader {
 	return &fileFuncLoader{
 		rfl:   rfl,
@@ -37,21 +218,35 @@ func (l *fileFuncLoader) Load() (*Values, error) {
 		if err != nil {
 			return nil, err
 		}
-		values.Merge(NewKey(), temp)
+		values.mergeSource(NewKey(), temp, "file "+path)
 	}
 	return values, nil
 }
 
+//Name returns "file " followed by the comma separated paths of l.
+func (l *fileFuncLoader) Name() string {
+	return "file " + strings.Join(l.paths, ", ")
+}
+
+//Paths returns the paths of l.
+func (l *fileFuncLoader) Paths() []string {
+	return append([]string(nil), l.paths...)
+}
+
 func (l *fileFuncLoader) loadPath(path string) (*Values, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, &LoadError{Loader: l, Path: path, Err: err}
 	}
 	values, err := l.rfl(file)
 	if err != nil {
-		return nil, err
+		file.Close()
+		return nil, &LoadError{Loader: l, Path: path, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return nil, &LoadError{Loader: l, Path: path, Err: err}
 	}
-	return values, file.Close()
+	return values, nil
 }
 
 type readerFuncLoader struct {
@@ -60,6 +255,8 @@ type readerFuncLoader struct {
 }
 
 //NewReaderFuncLoader creates a Loader that return rfl(r) in its Load() method.
+//An error from rfl is returned as a *LoadError whose Path is r's Name(), if r has
+//a Name() method such as an *os.File.
 func NewReaderFuncLoader(rfl ReaderFuncLoader, r io.Reader) Loader {
 	return &readerFuncLoader{
 		rfl: rfl,
@@ -68,5 +265,22 @@ func NewReaderFuncLoader(rfl ReaderFuncLoader, r io.Reader) Loader {
 }
 
 func (l *readerFuncLoader) Load() (*Values, error) {
-	return l.rfl(l.r)
+	values, err := l.rfl(l.r)
+	if err != nil {
+		loadError := &LoadError{Loader: l, Err: err}
+		if named, ok := l.r.(interface{ Name() string }); ok {
+			loadError.Path = named.Name()
+		}
+		return nil, loadError
+	}
+	return values, nil
+}
+
+//Name returns "file <name>" if l's io.Reader has a Name() method, such as an
+//*os.File, and "reader" otherwise.
+func (l *readerFuncLoader) Name() string {
+	if named, ok := l.r.(interface{ Name() string }); ok {
+		return "file " + named.Name()
+	}
+	return "reader"
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -8,6 +10,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewFileFuncLoader(t *testing.T) {
@@ -61,6 +64,57 @@ func TestFileFuncLoader_Load(t *testing.T) {
 	if !v.Equal(want) || err != nil {
 		t.Fail()
 	}
+	if source, ok := v.Source(nil); source != "file "+file.Name() || !ok {
+		t.Errorf("v.Source() = %v, %v", source, ok)
+	}
+}
+
+func TestFileFuncLoader_Load_error(t *testing.T) {
+	file, err := ioutil.TempFile("", "gogolfing.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	parseErr := fmt.Errorf("parse")
+	l := NewFileFuncLoader(
+		func(r io.Reader) (*Values, error) { return nil, parseErr },
+		file.Name(),
+	)
+	_, err = l.Load()
+	loadError, ok := err.(*LoadError)
+	if !ok || loadError.Loader != l || loadError.Path != file.Name() || loadError.Err != parseErr {
+		t.Errorf("err = %#v", err)
+	}
+
+	l = NewFileFuncLoader(nil, "/does/not/exist")
+	_, err = l.Load()
+	loadError, ok = err.(*LoadError)
+	if !ok || loadError.Path != "/does/not/exist" || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("err = %#v", err)
+	}
+}
+
+func TestFileFuncLoader_Name(t *testing.T) {
+	l := NewFileFuncLoader(nil, "a.json", "b.json")
+	if name := LoaderName(l); name != "file a.json, b.json" {
+		t.Errorf("LoaderName() = %v", name)
+	}
+}
+
+func TestLoaderPaths(t *testing.T) {
+	l := NewFileFuncLoader(nil, "a.json", "b.json")
+	want := []string{"a.json", "b.json"}
+	if paths := LoaderPaths(l); !reflect.DeepEqual(paths, want) {
+		t.Errorf("LoaderPaths() = %v WANT %v", paths, want)
+	}
+	if paths := LoaderPaths(WithPolicy(l, LoadOptional)); !reflect.DeepEqual(paths, want) {
+		t.Errorf("LoaderPaths(WithPolicy()) = %v WANT %v", paths, want)
+	}
+	if paths := LoaderPaths(intLoader(1)); paths != nil {
+		t.Errorf("LoaderPaths(intLoader) = %v WANT nil", paths)
+	}
 }
 
 func TestNewReaderFuncLoader(t *testing.T) {
@@ -104,3 +158,142 @@ func TestReaderFuncLoader_Load(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestReaderFuncLoader_Load_error(t *testing.T) {
+	parseErr := fmt.Errorf("parse")
+	rfl := func(r io.Reader) (*Values, error) { return nil, parseErr }
+
+	l := NewReaderFuncLoader(rfl, strings.NewReader(""))
+	_, err := l.Load()
+	if loadError, ok := err.(*LoadError); !ok || loadError.Loader != l || loadError.Path != "" || loadError.Err != parseErr {
+		t.Errorf("err = %#v", err)
+	}
+
+	l = NewReaderFuncLoader(rfl, os.Stdin)
+	_, err = l.Load()
+	if loadError, ok := err.(*LoadError); !ok || loadError.Path != os.Stdin.Name() {
+		t.Errorf("err = %#v", err)
+	}
+}
+
+func TestReaderFuncLoader_Name(t *testing.T) {
+	l := NewReaderFuncLoader(nil, strings.NewReader(""))
+	if name := LoaderName(l); name != "reader" {
+		t.Errorf("LoaderName() = %v", name)
+	}
+
+	l = NewReaderFuncLoader(nil, os.Stdin)
+	if name := LoaderName(l); name != "file "+os.Stdin.Name() {
+		t.Errorf("LoaderName() = %v", name)
+	}
+}
+
+func TestLoaderName(t *testing.T) {
+	if name := LoaderName(intLoader(1)); name != "config.intLoader" {
+		t.Errorf("LoaderName() = %v", name)
+	}
+}
+
+func TestLoadContext(t *testing.T) {
+	values, err := LoadContext(context.Background(), intLoader(1))
+	if err != nil || values.Get(NewKey("1")) != 1 {
+		t.Errorf("LoadContext(intLoader) = %v, %v WANT 1, nil", values, err)
+	}
+
+	values, err = LoadContext(context.Background(), contextLoader{})
+	if err != nil || values.Get(NewKey("context")) != true {
+		t.Errorf("LoadContext(contextLoader) = %v, %v WANT LoadContext()", values, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := LoadContext(ctx, intLoader(1)); err != context.Canceled {
+		t.Errorf("LoadContext(canceled) error = %v WANT %v", err, context.Canceled)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	block := blockingLoader(make(chan struct{}))
+	defer close(block)
+	if _, err := LoadContext(ctx, block); err != context.DeadlineExceeded {
+		t.Errorf("LoadContext(blockingLoader) error = %v WANT %v", err, context.DeadlineExceeded)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := LoadContext(ctx, contextLoader{block: true}); err != context.DeadlineExceeded {
+		t.Errorf("LoadContext(contextLoader) error = %v WANT %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestWithPolicy(t *testing.T) {
+	missing := NewFileFuncLoader(func(io.Reader) (*Values, error) { return NewValues(), nil }, "/does/not/exist")
+	_, missingErr := missing.Load()
+	otherErr := fmt.Errorf("other")
+
+	tests := []struct {
+		policy     LoadPolicy
+		missingErr bool
+		otherErr   bool
+	}{
+		{LoadRequired, false, false},
+		{LoadOptional, true, true},
+		{LoadIgnoreMissing, true, false},
+	}
+	for _, test := range tests {
+		l := WithPolicy(missing, test.policy)
+		if policy := LoaderPolicy(l); policy != test.policy {
+			t.Errorf("LoaderPolicy() = %v WANT %v", policy, test.policy)
+		}
+		if ignores := test.policy.Ignores(missingErr); ignores != test.missingErr {
+			t.Errorf("%v ignores missing = %v WANT %v", test.policy, ignores, test.missingErr)
+		}
+		if ignores := test.policy.Ignores(otherErr); ignores != test.otherErr {
+			t.Errorf("%v ignores other = %v WANT %v", test.policy, ignores, test.otherErr)
+		}
+		if name := LoaderName(l); name != "file /does/not/exist" {
+			t.Errorf("LoaderName() = %v", name)
+		}
+	}
+	for _, err := range []error{context.Canceled, fmt.Errorf("load: %w", context.DeadlineExceeded)} {
+		if LoadOptional.Ignores(err) {
+			t.Errorf("LoadOptional should not ignore %v", err)
+		}
+	}
+	if policy := LoaderPolicy(missing); policy != LoadRequired {
+		t.Errorf("LoaderPolicy() = %v WANT %v", policy, LoadRequired)
+	}
+
+	values, err := LoadContext(context.Background(), WithPolicy(contextLoader{}, LoadOptional))
+	if err != nil || values.Get(NewKey("context")) != true {
+		t.Errorf("LoadContext() = %v, %v WANT LoadContext()", values, err)
+	}
+}
+
+//blockingLoader is a Loader whose Load() blocks until it is closed.
+type blockingLoader chan struct{}
+
+func (l blockingLoader) Load() (*Values, error) {
+	<-l
+	return NewValues(), nil
+}
+
+//contextLoader is a ContextLoader whose LoadContext() blocks until ctx is done
+//if block is true.
+type contextLoader struct {
+	block bool
+}
+
+func (l contextLoader) Load() (*Values, error) {
+	return nil, fmt.Errorf("Load() should not be called")
+}
+
+func (l contextLoader) LoadContext(ctx context.Context) (*Values, error) {
+	if l.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	v := NewValues()
+	v.Put(NewKey("context"), true)
+	return v, nil
+}
@@ -0,0 +1,245 @@
+//Package combine provides functions that wrap and compose config.Loaders, so
+//that pipelines given to config.Config.AddLoaders() can be declared instead of
+//written as one off Loaders.
+//
+//Every Loader returned by this package keeps the sources recorded by the Loaders
+//it wraps, has the name, paths, and LoadPolicy of the Loader it wraps (see
+//config.Named, config.FileLoader, and config.PolicyLoader), and honors contexts
+//as the wrapped Loader does (see config.ContextLoader).
+package combine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gogolfing/config"
+)
+
+//Mount returns a Loader that places all values loaded by l under key, e.g.
+//a value at [host] is placed at [db host] by Mount(config.NewKey("db"), l).
+func Mount(key config.Key, l config.Loader) config.Loader {
+	key = config.NewKey(key...)
+	return transform(l, func(values *config.Values) (*config.Values, error) {
+		return mapEntries(values, func(e entry) (entry, bool, error) {
+			e.key = key.Append(e.key)
+			return e, true, nil
+		})
+	})
+}
+
+//Filter returns a Loader that keeps only the values loaded by l for which keep
+//returns true.
+func Filter(l config.Loader, keep func(key config.Key, value interface{}) bool) config.Loader {
+	return transform(l, func(values *config.Values) (*config.Values, error) {
+		return mapEntries(values, func(e entry) (entry, bool, error) {
+			return e, keep(e.key, e.value), nil
+		})
+	})
+}
+
+//RenameKeys returns a Loader that moves the values loaded by l according to
+//renames, whose keys and values are parsed by config.PeriodSeparatorKeyParser.
+//A value whose Key starts with a key of renames has that prefix replaced by the
+//associated value, e.g. {"database": "db"} moves [database host] to [db host].
+//The longest matching prefix is used, and values that match none are kept as
+//they are.
+func RenameKeys(l config.Loader, renames map[string]string) config.Loader {
+	type rename struct {
+		from, to config.Key
+	}
+	parsed := make([]rename, 0, len(renames))
+	for from, to := range renames {
+		parsed = append(parsed, rename{
+			config.PeriodSeparatorKeyParser.Parse(from),
+			config.PeriodSeparatorKeyParser.Parse(to),
+		})
+	}
+	return RenameKeysFunc(l, func(key config.Key) config.Key {
+		var longest *rename
+		for i, r := range parsed {
+			if key.StartsWith(r.from) && (longest == nil || r.from.Len() > longest.from.Len()) {
+				longest = &parsed[i]
+			}
+		}
+		if longest == nil {
+			return key
+		}
+		return longest.to.Append(key[longest.from.Len():])
+	})
+}
+
+//RenameKeysFunc returns a Loader that moves each value loaded by l from its Key
+//to rename(Key).
+//If several values are moved to the same Key, then the last one visited in Key
+//order is kept.
+func RenameKeysFunc(l config.Loader, rename func(key config.Key) config.Key) config.Loader {
+	return transform(l, func(values *config.Values) (*config.Values, error) {
+		return mapEntries(values, func(e entry) (entry, bool, error) {
+			e.key = rename(e.key)
+			return e, true, nil
+		})
+	})
+}
+
+//MapValues returns a Loader that replaces each value loaded by l with the result
+//of fn, e.g. to parse durations from strings.
+//If fn returns an error, then loading fails with that error.
+func MapValues(l config.Loader, fn func(key config.Key, value interface{}) (interface{}, error)) config.Loader {
+	return transform(l, func(values *config.Values) (*config.Values, error) {
+		return mapEntries(values, func(e entry) (entry, bool, error) {
+			value, err := fn(e.key, e.value)
+			e.value = value
+			return e, err == nil, err
+		})
+	})
+}
+
+//Optional returns a Loader that loads empty Values instead of failing if l fails
+//with an error that wraps os.ErrNotExist, e.g. because a file does not exist.
+//All other errors are returned as they are.
+//See config.WithPolicy() for the equivalent LoadPolicy.
+func Optional(l config.Loader) config.Loader {
+	return &loader{
+		name:   config.LoaderName(l),
+		paths:  config.LoaderPaths(l),
+		policy: config.LoaderPolicy(l),
+		load: func(ctx context.Context) (*config.Values, error) {
+			values, err := config.LoadContext(ctx, l)
+			if errors.Is(err, os.ErrNotExist) {
+				return config.NewValues(), nil
+			}
+			return values, err
+		},
+	}
+}
+
+//Merge returns a Loader that loads all of loaders in order and merges their
+//values, as config.Config.MergeLoaders() does, so that later loaders override
+//earlier ones. Lists loaded by a later loader replace those of earlier ones as a
+//whole (see config.Values.Merge()).
+//Values that a loader does not record a source for are given that loader's name
+//as their source.
+//Loading fails with the first error of any loader that its LoadPolicy does not
+//ignore (see config.WithPolicy()), while loaders with ignored errors provide no
+//values. The result itself has the LoadRequired policy.
+func Merge(loaders ...config.Loader) config.Loader {
+	names := make([]string, len(loaders))
+	paths := []string{}
+	for i, l := range loaders {
+		names[i] = config.LoaderName(l)
+		paths = append(paths, config.LoaderPaths(l)...)
+	}
+	return &loader{
+		name:  strings.Join(names, ", "),
+		paths: paths,
+		load: func(ctx context.Context) (*config.Values, error) {
+			result := config.NewValues()
+			for i, l := range loaders {
+				values, err := config.LoadContext(ctx, l)
+				if err != nil {
+					if config.LoaderPolicy(l).Ignores(err) {
+						continue
+					}
+					return nil, err
+				}
+				result.MergeSource(nil, values, names[i])
+			}
+			return result, nil
+		},
+	}
+}
+
+//loader is the config.ContextLoader returned by this package's functions.
+type loader struct {
+	name   string
+	paths  []string
+	policy config.LoadPolicy
+	load   func(ctx context.Context) (*config.Values, error)
+}
+
+//transform returns a loader with l's name, paths, and policy that loads l and returns the result
+//of fn on the loaded values.
+func transform(l config.Loader, fn func(values *config.Values) (*config.Values, error)) *loader {
+	return &loader{
+		name:   config.LoaderName(l),
+		paths:  config.LoaderPaths(l),
+		policy: config.LoaderPolicy(l),
+		load: func(ctx context.Context) (*config.Values, error) {
+			values, err := config.LoadContext(ctx, l)
+			if err != nil {
+				return nil, err
+			}
+			return fn(values)
+		},
+	}
+}
+
+func (l *loader) Load() (*config.Values, error) {
+	return l.LoadContext(context.Background())
+}
+
+func (l *loader) LoadContext(ctx context.Context) (*config.Values, error) {
+	return l.load(ctx)
+}
+
+//Name returns the name of the wrapped Loader, or the comma separated names of
+//the Loaders given to Merge().
+func (l *loader) Name() string {
+	return l.name
+}
+
+//Paths returns the paths of the wrapped Loader, or of all Loaders given to Merge().
+func (l *loader) Paths() []string {
+	return l.paths
+}
+
+//Policy returns the LoadPolicy of the wrapped Loader, or LoadRequired for Merge().
+func (l *loader) Policy() config.LoadPolicy {
+	return l.policy
+}
+
+//entry is a single value along with its Key and source.
+type entry struct {
+	key    config.Key
+	value  interface{}
+	source string
+}
+
+//mapEntries returns new Values with the result of fn for each entry in values,
+//in Key order, for which fn returns true.
+//The first error from fn is returned immediately.
+func mapEntries(values *config.Values, fn func(e entry) (entry, bool, error)) (*config.Values, error) {
+	entries := []entry{}
+	values.EachKeyValueSource(func(key config.Key, value interface{}, source string) {
+		entries = append(entries, entry{key, value, source})
+	})
+	sortEntries(entries)
+
+	result := config.NewValues()
+	for _, e := range entries {
+		e, keep, err := fn(e)
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			result.PutSource(e.key, e.value, e.source)
+		}
+	}
+	return result, nil
+}
+
+//sortEntries sorts entries by Key, comparing Keys part by part.
+func sortEntries(entries []entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].key, entries[j].key
+		for k := 0; k < a.Len() && k < b.Len(); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return a.Len() < b.Len()
+	})
+}
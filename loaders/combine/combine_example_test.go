@@ -0,0 +1,40 @@
+package combine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogolfing/config"
+	"github.com/gogolfing/config/loaders/json"
+)
+
+func Example() {
+	jsonLoader := &json.Loader{}
+	database := config.NewReaderFuncLoader(jsonLoader.LoadReader, strings.NewReader(`{
+		"hostname": "localhost",
+		"port": 5432,
+		"password": ""
+	}`))
+
+	c := config.New()
+	c.AddLoaders(
+		Mount(config.NewKey("db"), RenameKeys(
+			Filter(database, func(key config.Key, value interface{}) bool {
+				return value != ""
+			}),
+			map[string]string{"hostname": "host"},
+		)),
+		Optional(config.NewFileFuncLoader(jsonLoader.LoadReader, "/does/not/exist.json")),
+	)
+	if _, err := c.LoadAll(); err != nil {
+		fmt.Println(err)
+	}
+
+	fmt.Println(c.GetString("db.host"), c.GetInt64("db.port"))
+	_, ok := c.GetOk("db.password")
+	fmt.Println(ok)
+
+	//Output:
+	//localhost 5432
+	//false
+}
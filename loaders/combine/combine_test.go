@@ -0,0 +1,218 @@
+package combine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gogolfing/config"
+)
+
+//valuesLoader loads a copy of its Values.
+type valuesLoader struct {
+	values *config.Values
+}
+
+func (l valuesLoader) Load() (*config.Values, error) {
+	return l.values.Clone(), nil
+}
+
+func (l valuesLoader) Name() string {
+	return "values"
+}
+
+//errorLoader fails with its error.
+type errorLoader struct {
+	err error
+}
+
+func (l errorLoader) Load() (*config.Values, error) {
+	return nil, l.err
+}
+
+func newValuesLoader(keyValues ...interface{}) valuesLoader {
+	v := config.NewValues()
+	for i := 0; i < len(keyValues); i += 2 {
+		key := config.PeriodSeparatorKeyParser.Parse(keyValues[i].(string))
+		v.PutSource(key, keyValues[i+1], "source "+keyValues[i].(string))
+	}
+	return valuesLoader{v}
+}
+
+func testLoad(t *testing.T, l config.Loader, keyValues ...interface{}) *config.Values {
+	t.Helper()
+
+	v, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := config.NewValues()
+	for i := 0; i < len(keyValues); i += 2 {
+		want.Put(config.PeriodSeparatorKeyParser.Parse(keyValues[i].(string)), keyValues[i+1])
+	}
+	if !v.Equal(want) {
+		t.Errorf("Load() = %v WANT %v", v, want)
+	}
+	return v
+}
+
+func TestMount(t *testing.T) {
+	l := Mount(config.NewKey("db"), newValuesLoader("host", "localhost", "port", 5432))
+	v := testLoad(t, l, "db.host", "localhost", "db.port", 5432)
+
+	if source, _ := v.Source(config.NewKey("db", "host")); source != "source host" {
+		t.Errorf("Source() = %q WANT source host", source)
+	}
+	if name := config.LoaderName(l); name != "values" {
+		t.Errorf("LoaderName() = %q WANT values", name)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	l := Filter(newValuesLoader("a", 1, "b.c", 2, "b.d", 3), func(key config.Key, value interface{}) bool {
+		return value != 2
+	})
+	testLoad(t, l, "a", 1, "b.d", 3)
+}
+
+func TestRenameKeys(t *testing.T) {
+	l := RenameKeys(newValuesLoader("database.host", "h", "database.pool.size", 2, "other", 3), map[string]string{
+		"database":      "db",
+		"database.pool": "pool",
+	})
+	v := testLoad(t, l, "db.host", "h", "pool.size", 2, "other", 3)
+
+	if source, _ := v.Source(config.NewKey("pool", "size")); source != "source database.pool.size" {
+		t.Errorf("Source() = %q", source)
+	}
+}
+
+func TestRenameKeysFunc(t *testing.T) {
+	l := RenameKeysFunc(newValuesLoader("a", 1, "b", 2), func(key config.Key) config.Key {
+		return config.NewKey("same")
+	})
+	testLoad(t, l, "same", 2)
+}
+
+func TestMapValues(t *testing.T) {
+	l := MapValues(newValuesLoader("timeout", "1s", "name", "app"), func(key config.Key, value interface{}) (interface{}, error) {
+		if key.Equal(config.NewKey("timeout")) {
+			return time.ParseDuration(value.(string))
+		}
+		return value, nil
+	})
+	testLoad(t, l, "timeout", time.Second, "name", "app")
+
+	l = MapValues(newValuesLoader("timeout", "forever"), func(key config.Key, value interface{}) (interface{}, error) {
+		return time.ParseDuration(value.(string))
+	})
+	if v, err := l.Load(); v != nil || err == nil {
+		t.Errorf("Load() = %v, %v WANT error", v, err)
+	}
+}
+
+func TestOptional(t *testing.T) {
+	missing := config.NewFileFuncLoader(func(io.Reader) (*config.Values, error) { return config.NewValues(), nil }, "/does/not/exist")
+	testLoad(t, Optional(missing))
+	testLoad(t, Optional(newValuesLoader("a", 1)), "a", 1)
+
+	other := errors.New("other")
+	if _, err := Optional(errorLoader{other}).Load(); err != other {
+		t.Errorf("Load() error = %v WANT %v", err, other)
+	}
+	if name := config.LoaderName(Optional(missing)); name != "file /does/not/exist" {
+		t.Errorf("LoaderName() = %q", name)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	unsourced := config.NewValues()
+	unsourced.Put(config.NewKey("b"), "unsourced")
+
+	l := Merge(newValuesLoader("a", 1, "b", 1), valuesLoader{unsourced})
+	v := testLoad(t, l, "a", 1, "b", "unsourced")
+
+	if source, _ := v.Source(config.NewKey("a")); source != "source a" {
+		t.Errorf("Source(a) = %q", source)
+	}
+	if source, _ := v.Source(config.NewKey("b")); source != "values" {
+		t.Errorf("Source(b) = %q", source)
+	}
+	if name := config.LoaderName(l); name != "values, values" {
+		t.Errorf("LoaderName() = %q", name)
+	}
+
+	failed := errors.New("failed")
+	if _, err := Merge(newValuesLoader("a", 1), errorLoader{failed}).Load(); err != failed {
+		t.Errorf("Load() error = %v WANT %v", err, failed)
+	}
+}
+
+func TestMerge_lists(t *testing.T) {
+	list := func(elems ...interface{}) valuesLoader {
+		v := config.NewValues()
+		v.PutList(config.NewKey("a"), len(elems))
+		for i, elem := range elems {
+			v.Put(config.NewKey("a", strconv.Itoa(i)), elem)
+		}
+		return valuesLoader{v}
+	}
+
+	v := testLoad(t, Merge(list("x", "y", "z"), list("q")), "a.0", "q")
+	if s := v.GetSlice(config.NewKey("a")); !reflect.DeepEqual(s, []interface{}{"q"}) {
+		t.Errorf("GetSlice(a) = %v WANT [q]", s)
+	}
+}
+
+func TestLoader_Paths(t *testing.T) {
+	a := config.NewFileFuncLoader(nil, "a.json")
+	b := config.NewFileFuncLoader(nil, "b.json", "c.json")
+
+	l := Merge(Mount(config.NewKey("a"), a), Optional(b), newValuesLoader())
+	want := []string{"a.json", "b.json", "c.json"}
+	if paths := config.LoaderPaths(l); !reflect.DeepEqual(paths, want) {
+		t.Errorf("LoaderPaths() = %v WANT %v", paths, want)
+	}
+}
+
+func TestLoader_Policy(t *testing.T) {
+	failing := config.WithPolicy(errorLoader{errors.New("failed")}, config.LoadOptional)
+
+	mounted := Mount(config.NewKey("a"), failing)
+	if policy := config.LoaderPolicy(mounted); policy != config.LoadOptional {
+		t.Errorf("LoaderPolicy(Mount()) = %v WANT %v", policy, config.LoadOptional)
+	}
+	if policy := config.LoaderPolicy(Optional(failing)); policy != config.LoadOptional {
+		t.Errorf("LoaderPolicy(Optional()) = %v WANT %v", policy, config.LoadOptional)
+	}
+	c := config.New()
+	if _, err := c.MergeLoaders(mounted, newValuesLoader("b", 1)); err != nil || c.Get("b") != 1 {
+		t.Errorf("MergeLoaders() = %v, b = %v WANT nil, 1", err, c.Get("b"))
+	}
+
+	merged := Merge(failing, newValuesLoader("a", 1))
+	if policy := config.LoaderPolicy(merged); policy != config.LoadRequired {
+		t.Errorf("LoaderPolicy(Merge()) = %v WANT %v", policy, config.LoadRequired)
+	}
+	testLoad(t, merged, "a", 1)
+}
+
+func TestLoader_LoadContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	loaders := []config.Loader{
+		Mount(config.NewKey("a"), newValuesLoader("a", 1)),
+		Optional(newValuesLoader("a", 1)),
+		Merge(newValuesLoader("a", 1)),
+	}
+	for _, l := range loaders {
+		if _, err := config.LoadContext(ctx, l); err != context.Canceled {
+			t.Errorf("%T: LoadContext() error = %v WANT %v", l, err, context.Canceled)
+		}
+	}
+}
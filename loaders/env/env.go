@@ -37,6 +37,7 @@ func NewPrefixLowerUnderscoreLoader(prefix string) config.Loader {
 //reads in all entries from os.Environ() and inserts into the resulting Values all
 //key, value associations whose keys start with prefix.
 //The key inserted is parsed with parser after prefix is removed.
+//Each value's source is recorded as "env " followed by its variable name.
 func NewPrefixParserLoader(prefix string, parser config.KeyParser) config.Loader {
 	return &prefixParserLoader{
 		prefix: prefix,
@@ -50,12 +51,18 @@ func (p *prefixParserLoader) Load() (*config.Values, error) {
 	for _, envVar := range environment {
 		key, value := p.loadPossibleEnvironmentVariable(envVar)
 		if !key.IsEmpty() {
-			values.Put(key, value)
+			name := envVar[:strings.Index(envVar, Equal)]
+			values.PutSource(key, value, "env "+name)
 		}
 	}
 	return values, nil
 }
 
+//Name returns "env " followed by the prefix of p.
+func (p *prefixParserLoader) Name() string {
+	return "env " + p.prefix + "*"
+}
+
 func (p *prefixParserLoader) loadPossibleEnvironmentVariable(envVar string) (config.Key, string) {
 	equalIndex := strings.Index(envVar, Equal)
 	key, value := envVar[:equalIndex], envVar[equalIndex+1:]
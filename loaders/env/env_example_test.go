@@ -33,3 +33,38 @@ func ExampleNewPrefixLowerUnderscoreLoader() {
 	//Output:
 	//value true
 }
+
+func ExampleNewPrefixLowerUnderscoreLoader_coercion() {
+	prefix := "QWN3UIWHBNF8ENF__"
+
+	os.Setenv(prefix+"SERVER_PORT", "8080")
+	os.Setenv(prefix+"SERVER_DEBUG", "on")
+
+	c := config.New()
+	if _, err := c.MergeLoaders(NewPrefixLowerUnderscoreLoader(prefix)); err != nil {
+		fmt.Println(err)
+	}
+
+	fmt.Println(c.GetInt64Ok("server.port"))
+	fmt.Println(c.GetInt64As("server.port"))
+	fmt.Println(c.GetBoolAs("server.debug"))
+	//Output:
+	//0 false
+	//8080 <nil>
+	//true <nil>
+}
+
+func ExampleNewPrefixLowerUnderscoreLoader_source() {
+	prefix := "PWO3NF8EWNFLAQ__"
+
+	os.Setenv(prefix+"DB_HOST", "db.internal")
+
+	c := config.New()
+	if _, err := c.MergeLoaders(NewPrefixLowerUnderscoreLoader(prefix)); err != nil {
+		fmt.Println(err)
+	}
+
+	fmt.Println(c.Source("db.host"))
+	//Output:
+	//env PWO3NF8EWNFLAQ__DB_HOST true
+}
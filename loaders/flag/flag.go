@@ -67,17 +67,18 @@ func (l *Loader) AddAlias(name, alias string) *Loader {
 }
 
 //Load is the config.Loader required method.
-//It calls l.FlagSet.Parse(l.Args) if l.FlagSet.Parsed() is false.
+//It calls l.FlagSet.Parse(l.Args) if l.FlagSet.Parsed() is false, and returns
+//a *config.LoadError wrapping the error if parsing fails.
 //It then calls one of the flaglib.FlagSet.Visit*() methods depending on the value
 //of l.LoadDefaults, and parses each flag's Name or alias and inserts it into the
-//returned Values.
+//returned Values with the source "flag -<Name>".
 func (l *Loader) Load() (*config.Values, error) {
 	if !l.FlagSet.Parsed() {
 		args := l.Args
 		err := l.FlagSet.Parse(args)
 		if err != nil {
 			if err != flaglib.ErrHelp {
-				return nil, err
+				return nil, &config.LoadError{Loader: l, Err: err}
 			}
 		}
 	}
@@ -103,5 +104,10 @@ func (l *Loader) putFlagIntoValues(v *config.Values, f *flaglib.Flag) {
 		name = alias
 	}
 	key := l.KeyParser.Parse(name)
-	v.Put(key, getter.Get())
+	v.PutSource(key, getter.Get(), "flag -"+f.Name)
+}
+
+//Name returns "flag " followed by the name of l.FlagSet.
+func (l *Loader) Name() string {
+	return "flag " + l.FlagSet.Name()
 }
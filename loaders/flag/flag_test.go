@@ -30,6 +30,23 @@ func TestLoader_Load_idempotent(t *testing.T) {
 	testLoadWithWantedValues(t, l, want)
 }
 
+func TestLoader_Load_source(t *testing.T) {
+	l := New("app")
+	l.FlagSet.String("db-host", "", "")
+	l.Args = []string{"-db-host", "localhost"}
+
+	v, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source, ok := v.Source(config.NewKey("db", "host")); source != "flag -db-host" || !ok {
+		t.Errorf("v.Source() = %v, %v", source, ok)
+	}
+	if name := l.Name(); name != "flag app" {
+		t.Errorf("l.Name() = %v", name)
+	}
+}
+
 func TestLoader_Load_error(t *testing.T) {
 	l := New("")
 	l.Args = []string{"-a", "value"}
@@ -39,6 +56,9 @@ func TestLoader_Load_error(t *testing.T) {
 	if v != nil || err == nil {
 		t.Fail()
 	}
+	if loadError, ok := err.(*config.LoadError); !ok || loadError.Loader != l || loadError.Err == nil {
+		t.Errorf("err = %v WANT *config.LoadError", err)
+	}
 }
 
 func testLoadWithWantedValues(t *testing.T, l *Loader, want *config.Values) {
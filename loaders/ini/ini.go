@@ -0,0 +1,441 @@
+//Package ini provides a Loader type that can be used in conjunction with
+//the parent config package to create a config.Loader to load values from INI
+//files, including git config and php.ini style files.
+package ini
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gogolfing/config"
+)
+
+//Loader is a collection of settings that can be used with config.NewReaderFuncLoader()
+//or config.NewFileFuncLoader() in order to create a config.Loader that parses
+//INI files.
+//Loader itself is not a config.Loader.
+//The empty valued Loader has sane defaults where all key, values found within the
+//INI are included in the resulting Values.
+//See the individual fields for overriding this behaviour.
+//See the package examples for use with the config package.
+//
+//The syntax is that of git config files:
+//
+//	; comment
+//	# comment
+//	top = value before any section
+//
+//	[section]
+//	key = value ; comment
+//	quoted = "  keeps ; and # and surrounding space  "
+//	long = first \
+//	second
+//	bare
+//
+//	[section "sub section"]
+//	key = value
+//
+//Section headers are Key prefixes, e.g. the first key above is found at
+//config.NewKey("section", "key") and the second at
+//config.NewKey("section", "sub section", "key").
+//The section name is a single Key part, and so [a.b] is found at [a.b].
+//
+//Values are strings with surrounding whitespace removed. Comments start with an
+//unquoted ';' or '#'. Parts of a value may be double quoted, which keeps their
+//whitespace and comment characters. The escapes \", \\, \n, \t and \b are
+//supported; any other backslash is kept as is, so that Windows paths need no
+//escaping. A value ending with a backslash continues on the next line.
+//
+//A key without an '=' is inserted as true.
+//A key that is repeated within a section is a list of all of its values in order,
+//while a key that appears once is a single value, and so the shape of a key
+//depends on how often it appears:
+//
+//	[remote "origin"]
+//	fetch = a    ; [remote origin fetch 0] is a
+//	fetch = b    ; [remote origin fetch 1] is b
+//	url = u      ; [remote origin url] is u
+//
+//Set AlwaysLists for keys that may appear once or more to always be lists.
+type Loader struct {
+	//KeyPrefix is a Key that all Keys found in the INI must start with in order
+	//to be included in the resulting config.Values.
+	//Notice that an empty KeyPrefix means all Keys are matched.
+	KeyPrefix config.Key
+
+	//KeySuffix is a Key that all Keys found in the INI must end with in order
+	//to be included in the resulting config.Values.
+	//Notice that an empty KeySuffix means all Keys are matched.
+	KeySuffix config.Key
+
+	//KeyPartTransform is an optional function that is called (if not nil)
+	//on each section name and key found in the INI. The returned response from
+	//this function is then used to create the resulting Key.
+	//Subsection names are used as they are, since they are case sensitive in git
+	//config files.
+	//
+	//Use strings.ToLower for the case insensitivity of the section names and keys
+	//of git config files, e.g. [Remote "Origin"] is found at [remote Origin].
+	KeyPartTransform func(string) string
+
+	//ArraysAsSlices tells Loader whether to insert the values of repeated keys as
+	//single []interface{} values or to expand them into subtrees keyed by each
+	//value's index, e.g. [remote origin fetch 0].
+	//The zero value means the values are expanded.
+	//Keys that are not repeated are inserted as single values unless AlwaysLists
+	//is true.
+	//
+	//Expanded values are lists (see config.Values.PutList()), which replace each
+	//other as a whole when merged, e.g. by config.Config.MergeLoaders().
+	//
+	//See config.Values.GetSlice() and config.Values.Len() for accessing lists.
+	ArraysAsSlices bool
+
+	//AlwaysLists tells Loader to insert the values of every key as a list, as if
+	//it were repeated, so that a key that appears once is found at
+	//[section key 0], or as a []interface{} of its single value if ArraysAsSlices
+	//is true.
+	//The zero value means only repeated keys are lists.
+	AlwaysLists bool
+}
+
+//ParseError describes a line of INI that cannot be parsed.
+type ParseError struct {
+	//Line is the 1 based line number of the offending line.
+	Line int
+
+	//Err describes the problem.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("ini: line %d: %v", e.Line, e.Err)
+}
+
+//Unwrap returns e.Err.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+//ErrInvalidSection is the Err of a *ParseError for a malformed section header.
+var ErrInvalidSection = errors.New("invalid section header")
+
+//ErrInvalidKey is the Err of a *ParseError for a key that is empty or contains
+//whitespace.
+var ErrInvalidKey = errors.New("invalid key")
+
+//ErrUnterminatedQuote is the Err of a *ParseError for a value with an unclosed
+//double quote.
+var ErrUnterminatedQuote = errors.New("unterminated quote")
+
+//MaxLineLength is the length in bytes of the longest line that Loader parses,
+//not counting its line ending.
+const MaxLineLength = 1024 * 1024
+
+//LoadString uses l's settings and returns the parsed Values and possible error
+//from decoding in.
+//It is sugar for l.LoadBytes([]byte(in)).
+func (l *Loader) LoadString(in string) (*config.Values, error) {
+	return l.LoadBytes([]byte(in))
+}
+
+//LoadBytes uses l's settings and returns the parsed Values and possible error
+//from decoding in.
+//It is sugar for l.LoadReader(bytes.NewReader(in)).
+func (l *Loader) LoadBytes(in []byte) (*config.Values, error) {
+	return l.LoadReader(bytes.NewReader(in))
+}
+
+//LoadReader uses l's settings to parse Values from in.
+//If in cannot be parsed, then a *ParseError is returned with nil *Values.
+//Lines may be up to MaxLineLength bytes long, and a longer line is a
+//*ParseError wrapping bufio.ErrTooLong.
+//Errors from reading in are returned as is.
+//
+//Notice that LoadReader is a config.ReaderFuncLoader and it is used in this manner
+//in the examples.
+func (l *Loader) LoadReader(in io.Reader) (*config.Values, error) {
+	p := &parser{
+		loader:  l,
+		scanner: bufio.NewScanner(in),
+		index:   map[string]*entry{},
+	}
+	//The scanner's buffer also holds the line ending of the longest line.
+	p.scanner.Buffer(nil, MaxLineLength+len("\r\n"))
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	values := config.NewValues()
+	for _, e := range p.entries {
+		l.loadIntoValues(values, e)
+	}
+	return values, nil
+}
+
+func (l *Loader) loadIntoValues(values *config.Values, e *entry) {
+	if len(e.values) == 1 && !l.AlwaysLists {
+		l.loadSingleIntoValues(e.key, values, e.values[0])
+		return
+	}
+	if l.ArraysAsSlices {
+		l.loadSingleIntoValues(e.key, values, e.values)
+		return
+	}
+	loaded := false
+	for i, value := range e.values {
+		loaded = l.loadSingleIntoValues(e.key.AppendStrings(strconv.Itoa(i)), values, value) || loaded
+	}
+	if loaded || e.key.StartsWith(l.KeyPrefix) && e.key.EndsWith(l.KeySuffix) {
+		values.PutList(e.key, len(e.values))
+	}
+}
+
+func (l *Loader) loadSingleIntoValues(key config.Key, values *config.Values, value interface{}) bool {
+	if !key.StartsWith(l.KeyPrefix) || !key.EndsWith(l.KeySuffix) {
+		return false
+	}
+	values.Put(key, value)
+	return true
+}
+
+func (l *Loader) keyPart(part string) string {
+	if l.KeyPartTransform != nil {
+		return l.KeyPartTransform(part)
+	}
+	return part
+}
+
+//entry holds all values of a single key in the order they were found.
+type entry struct {
+	key    config.Key
+	values []interface{}
+}
+
+//parser parses the lines of an INI file into entries.
+type parser struct {
+	loader  *Loader
+	scanner *bufio.Scanner
+	line    int
+	err     error
+
+	section config.Key
+	entries []*entry
+	index   map[string]*entry
+}
+
+func (p *parser) parse() error {
+	for p.scan() {
+		line := strings.TrimSpace(p.scanner.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		var err error
+		if line[0] == '[' {
+			err = p.parseSection(line)
+		} else {
+			err = p.parseKeyValue(line)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return p.err
+}
+
+//scan advances to the next line. It returns false at the end of in or once a
+//line cannot be read, after which p.err holds the reason.
+func (p *parser) scan() bool {
+	if p.err != nil {
+		return false
+	}
+	if !p.scanner.Scan() {
+		p.err = p.scanner.Err()
+		if errors.Is(p.err, bufio.ErrTooLong) {
+			//The line that is too long has not been counted.
+			p.err = &ParseError{Line: p.line + 1, Err: p.err}
+		}
+		return false
+	}
+	p.line++
+	if len(p.scanner.Bytes()) > MaxLineLength {
+		p.err = p.error(bufio.ErrTooLong)
+		return false
+	}
+	return true
+}
+
+func (p *parser) error(err error) *ParseError {
+	return &ParseError{
+		Line: p.line,
+		Err:  err,
+	}
+}
+
+//parseSection parses the section header line, which starts with '['.
+func (p *parser) parseSection(line string) error {
+	end := strings.IndexAny(line, " \t\"]")
+	if end < 0 {
+		return p.error(ErrInvalidSection)
+	}
+	name := line[1:end]
+	if name == "" {
+		return p.error(ErrInvalidSection)
+	}
+	section := config.NewKey(p.loader.keyPart(name))
+
+	rest := strings.TrimLeft(line[end:], " \t")
+	if rest != "" && rest[0] == '"' {
+		sub, n, ok := parseSubsection(rest)
+		if !ok {
+			return p.error(ErrInvalidSection)
+		}
+		section = section.AppendStrings(sub)
+		rest = strings.TrimLeft(rest[n:], " \t")
+	}
+	if rest == "" || rest[0] != ']' || !isComment(rest[1:]) {
+		return p.error(ErrInvalidSection)
+	}
+	p.section = section
+	return nil
+}
+
+//parseSubsection parses the quoted subsection name at the start of s and returns
+//it along with the number of bytes of s it spans.
+func parseSubsection(s string) (string, int, bool) {
+	b := strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return b.String(), i + 1, true
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", 0, false
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return "", 0, false
+}
+
+//isComment determines whether or not s holds only whitespace and a possible comment.
+func isComment(s string) bool {
+	s = strings.TrimLeft(s, " \t")
+	return s == "" || s[0] == ';' || s[0] == '#'
+}
+
+//parseKeyValue parses the key = value, or bare key, line, which may be continued
+//on the following lines.
+func (p *parser) parseKeyValue(line string) error {
+	var value interface{} = true
+	name := line
+	if i := strings.IndexAny(line, "=;#"); i >= 0 {
+		name = line[:i]
+		if line[i] == '=' {
+			parsed, err := p.parseValue(line[i+1:])
+			if err != nil {
+				return err
+			}
+			value = parsed
+		}
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t\"") {
+		return p.error(ErrInvalidKey)
+	}
+	p.add(p.section.AppendStrings(p.loader.keyPart(name)), value)
+	return nil
+}
+
+//parseValue parses the value that starts with s, continuing on the following
+//lines while they end with a backslash.
+func (p *parser) parseValue(s string) (string, error) {
+	b := strings.Builder{}
+	//space holds unquoted whitespace that is only written once it is followed
+	//by more of the value, so that surrounding whitespace is removed.
+	space := strings.Builder{}
+	started := false
+	quoted := false
+
+	write := func(c byte) {
+		if started {
+			b.WriteString(space.String())
+		}
+		space.Reset()
+		started = true
+		b.WriteByte(c)
+	}
+
+	for {
+		continued := false
+	line:
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case c == '\\':
+				if i == len(s)-1 {
+					continued = true
+					break line
+				}
+				if escaped, ok := escapes[s[i+1]]; ok {
+					i++
+					write(escaped)
+				} else {
+					write(c)
+				}
+			case c == '"':
+				if started {
+					b.WriteString(space.String())
+				}
+				space.Reset()
+				started = true
+				quoted = !quoted
+			case quoted:
+				write(c)
+			case c == ';' || c == '#':
+				break line
+			case c == ' ' || c == '\t':
+				space.WriteByte(c)
+			default:
+				write(c)
+			}
+		}
+		if !continued {
+			break
+		}
+		if !p.scan() {
+			break
+		}
+		s = p.scanner.Text()
+	}
+	if quoted {
+		return "", p.error(ErrUnterminatedQuote)
+	}
+	return b.String(), nil
+}
+
+//escapes maps the characters that may follow a backslash to their values.
+var escapes = map[byte]byte{
+	'"':  '"',
+	'\\': '\\',
+	'n':  '\n',
+	't':  '\t',
+	'b':  '\b',
+}
+
+//add appends value to the values of key.
+func (p *parser) add(key config.Key, value interface{}) {
+	index := strings.Join(key, "\x00")
+	e, ok := p.index[index]
+	if !ok {
+		e = &entry{key: key}
+		p.index[index] = e
+		p.entries = append(p.entries, e)
+	}
+	e.values = append(e.values, value)
+}
@@ -0,0 +1,65 @@
+package ini
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogolfing/config"
+)
+
+func Example() {
+	input := `
+[core]
+	editor = vim
+	bare
+
+[remote "origin"]
+	url = "https://example.com/repo.git" ; the upstream
+`
+
+	c := config.New().AddLoaders(
+		config.NewReaderFuncLoader(
+			(&Loader{KeyPartTransform: strings.ToLower}).LoadReader,
+			strings.NewReader(input),
+		),
+	)
+	if _, err := c.LoadAll(); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(c.GetString("core.editor"))
+	fmt.Println(c.GetBool("core.bare"))
+	fmt.Println(c.GetString("remote.origin.url"))
+	//Output:
+	//vim
+	//true
+	//https://example.com/repo.git
+}
+
+func Example_repeatedKeys() {
+	input := `
+[remote "origin"]
+	url = https://example.com/repo.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	fetch = +refs/tags/*:refs/tags/*
+`
+
+	for _, loader := range []*Loader{{}, {AlwaysLists: true}} {
+		values, err := loader.LoadString(input)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(values.Get(config.NewKey("remote", "origin", "url")))
+		fmt.Println(values.Get(config.NewKey("remote", "origin", "url", "0")))
+		fmt.Println(values.Get(config.NewKey("remote", "origin", "fetch", "1")))
+	}
+	//Output:
+	//https://example.com/repo.git
+	//<nil>
+	//+refs/tags/*:refs/tags/*
+	//[https://example.com/repo.git]
+	//https://example.com/repo.git
+	//+refs/tags/*:refs/tags/*
+}
@@ -0,0 +1,226 @@
+package ini
+
+import (
+	"bufio"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gogolfing/config"
+)
+
+func TestLoader_LoadString_sections(t *testing.T) {
+	in := `
+top = root
+
+[core]
+	editor = vim
+	bare
+
+[remote "origin"]
+	url = https://example.com/repo.git
+
+[branch "feature \"x\" \\ y"]
+	merge = refs/heads/x
+
+[php.ini] ; comment
+date.timezone=UTC
+[empty]
+`
+	want := config.NewValues()
+	want.Put(config.NewKey("top"), "root")
+	want.Put(config.NewKey("core", "editor"), "vim")
+	want.Put(config.NewKey("core", "bare"), true)
+	want.Put(config.NewKey("remote", "origin", "url"), "https://example.com/repo.git")
+	want.Put(config.NewKey("branch", `feature "x" \ y`, "merge"), "refs/heads/x")
+	want.Put(config.NewKey("php.ini", "date.timezone"), "UTC")
+	testLoadStringWithWantedValues(t, &Loader{}, in, want)
+}
+
+func TestLoader_LoadString_values(t *testing.T) {
+	in := `
+; comment
+# comment
+[s]
+empty =
+spaces =   a  b   
+comment = a ; comment
+hash = a#comment
+quoted = "  a ; b # c  "
+partly = a "b  c" d
+escapes = "\"\\\n\t"
+path = C:\php\ext
+continued = first \
+second
+quotedContinued = "a \
+ b"
+bareComment ; comment
+`
+	want := config.NewValues()
+	want.Put(config.NewKey("s", "empty"), "")
+	want.Put(config.NewKey("s", "spaces"), "a  b")
+	want.Put(config.NewKey("s", "comment"), "a")
+	want.Put(config.NewKey("s", "hash"), "a")
+	want.Put(config.NewKey("s", "quoted"), "  a ; b # c  ")
+	want.Put(config.NewKey("s", "partly"), "a b  c d")
+	want.Put(config.NewKey("s", "escapes"), "\"\\\n\t")
+	want.Put(config.NewKey("s", "path"), `C:\php\ext`)
+	want.Put(config.NewKey("s", "continued"), "first second")
+	want.Put(config.NewKey("s", "quotedContinued"), "a  b")
+	want.Put(config.NewKey("s", "bareComment"), true)
+	testLoadStringWithWantedValues(t, &Loader{}, in, want)
+}
+
+const repeatedInput = `
+[remote "origin"]
+fetch = a
+url = u
+fetch = b
+[other]
+fetch = c
+[remote "origin"]
+fetch = d
+`
+
+func TestLoader_LoadString_repeated(t *testing.T) {
+	want := config.NewValues()
+	want.Put(config.NewKey("remote", "origin", "fetch", "0"), "a")
+	want.Put(config.NewKey("remote", "origin", "fetch", "1"), "b")
+	want.Put(config.NewKey("remote", "origin", "fetch", "2"), "d")
+	want.Put(config.NewKey("remote", "origin", "url"), "u")
+	want.Put(config.NewKey("other", "fetch"), "c")
+	testLoadStringWithWantedValues(t, &Loader{}, repeatedInput, want)
+
+	v, err := (&Loader{ArraysAsSlices: true}).LoadString(repeatedInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFetch := []interface{}{"a", "b", "d"}
+	if fetch := v.Get(config.NewKey("remote", "origin", "fetch")); !reflect.DeepEqual(fetch, wantFetch) {
+		t.Errorf("fetch = %#v WANT %#v", fetch, wantFetch)
+	}
+}
+
+func TestLoader_LoadString_alwaysLists(t *testing.T) {
+	want := config.NewValues()
+	want.Put(config.NewKey("remote", "origin", "fetch", "0"), "a")
+	want.Put(config.NewKey("remote", "origin", "fetch", "1"), "b")
+	want.Put(config.NewKey("remote", "origin", "fetch", "2"), "d")
+	want.Put(config.NewKey("remote", "origin", "url", "0"), "u")
+	want.Put(config.NewKey("other", "fetch", "0"), "c")
+	testLoadStringWithWantedValues(t, &Loader{AlwaysLists: true}, repeatedInput, want)
+
+	want = config.NewValues()
+	want.Put(config.NewKey("remote", "origin", "fetch"), []interface{}{"a", "b", "d"})
+	want.Put(config.NewKey("remote", "origin", "url"), []interface{}{"u"})
+	want.Put(config.NewKey("other", "fetch"), []interface{}{"c"})
+	testLoadStringWithWantedValues(t, &Loader{AlwaysLists: true, ArraysAsSlices: true}, repeatedInput, want)
+}
+
+func TestLoader_LoadReader_repeatedOverride(t *testing.T) {
+	c := config.New()
+	_, err := c.MergeLoaders(
+		config.NewReaderFuncLoader((&Loader{}).LoadReader, strings.NewReader("[a]\nb = 1\nb = 2\nb = 3")),
+		config.NewReaderFuncLoader((&Loader{}).LoadReader, strings.NewReader("[a]\nb = x\nb = y")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := c.GetSlice("a.b"); !reflect.DeepEqual(b, []interface{}{"x", "y"}) {
+		t.Errorf("a.b = %v WANT [x y]", b)
+	}
+}
+
+func TestLoader_LoadString_settings(t *testing.T) {
+	in := `
+[A "b"]
+C = c
+[D]
+C = d
+`
+	l := &Loader{
+		KeyPrefix:        config.NewKey("a"),
+		KeySuffix:        config.NewKey("c"),
+		KeyPartTransform: strings.ToLower,
+	}
+	want := config.NewValues()
+	want.Put(config.NewKey("a", "b", "c"), "c")
+	testLoadStringWithWantedValues(t, l, in, want)
+}
+
+func TestLoader_LoadString_subsectionCase(t *testing.T) {
+	in := `
+[Remote "Origin"]
+URL = u
+[remote "origin"]
+url = v
+`
+	want := config.NewValues()
+	want.Put(config.NewKey("remote", "Origin", "url"), "u")
+	want.Put(config.NewKey("remote", "origin", "url"), "v")
+	testLoadStringWithWantedValues(t, &Loader{KeyPartTransform: strings.ToLower}, in, want)
+}
+
+func TestLoader_LoadString_empty(t *testing.T) {
+	testLoadStringWithWantedValues(t, &Loader{}, "", config.NewValues())
+	testLoadStringWithWantedValues(t, &Loader{}, "; comment only\n\n", config.NewValues())
+}
+
+func TestLoader_LoadString_errors(t *testing.T) {
+	tests := []struct {
+		in   string
+		line int
+		err  error
+	}{
+		{"[]", 1, ErrInvalidSection},
+		{"[a", 1, ErrInvalidSection},
+		{"\n[a] b", 2, ErrInvalidSection},
+		{`[a "b]`, 1, ErrInvalidSection},
+		{`[a "b" c]`, 1, ErrInvalidSection},
+		{"= a", 1, ErrInvalidKey},
+		{"a b = c", 1, ErrInvalidKey},
+		{"a = \"b\nc = d", 1, ErrUnterminatedQuote},
+		{"a = \"b \\\nc", 2, ErrUnterminatedQuote},
+	}
+	for _, test := range tests {
+		v, err := (&Loader{}).LoadString(test.in)
+		if v != nil {
+			t.Errorf("%q: v = %v WANT nil", test.in, v)
+		}
+		parseError := &ParseError{}
+		if !errors.As(err, &parseError) || parseError.Line != test.line || parseError.Err != test.err {
+			t.Errorf("%q: err = %v WANT %v on line %v", test.in, err, test.err, test.line)
+		}
+	}
+}
+
+func TestLoader_LoadString_longLines(t *testing.T) {
+	long := strings.Repeat("b", MaxLineLength-len("a = "))
+
+	want := config.NewValues()
+	want.Put(config.NewKey("a"), long)
+	want.Put(config.NewKey("c"), "d")
+	testLoadStringWithWantedValues(t, &Loader{}, "a = "+long+"\r\nc = d", want)
+
+	for _, tooLong := range []string{long + "b", long + strings.Repeat("b", 1024)} {
+		v, err := (&Loader{}).LoadString("[s]\na = " + tooLong + "\nc = d")
+		if v != nil {
+			t.Error("v should be nil")
+		}
+		parseError := &ParseError{}
+		if !errors.As(err, &parseError) || parseError.Line != 2 || !errors.Is(err, bufio.ErrTooLong) {
+			t.Errorf("err = %v WANT %v on line 2", err, bufio.ErrTooLong)
+		}
+	}
+}
+
+func testLoadStringWithWantedValues(t *testing.T, l *Loader, in string, want *config.Values) {
+	v, err := l.LoadString(in)
+	if err != nil {
+		t.Error(err)
+	}
+	if !v.Equal(want) {
+		t.Fail()
+	}
+}
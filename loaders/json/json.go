@@ -6,7 +6,13 @@ package json
 import (
 	"bytes"
 	jsonlib "encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/gogolfing/config"
 )
@@ -53,8 +59,28 @@ type Loader struct {
 	//
 	//See the examples for use of this function.
 	KeyPartTransform func(string) string
+
+	//ArraysAsSlices tells Loader whether to insert JSON arrays as single []interface{}
+	//values or to expand them into subtrees keyed by each element's index, so that
+	//elements are reachable by Key, e.g. [servers 0 host].
+	//The zero value means non empty arrays are expanded. Empty arrays are always
+	//inserted as empty []interface{} values.
+	//
+	//Expanded arrays are lists, which replace each other as a whole when merged,
+	//e.g. by config.Config.MergeLoaders(), just as []interface{} values do.
+	//
+	//See config.Values.GetSlice() and config.Values.Len() for accessing lists.
+	ArraysAsSlices bool
+
+	//Indent is the indentation used for each nesting level by the Encode
+	//methods. The zero value means the output is compact.
+	Indent string
 }
 
+//ErrRootValue is returned by the Encode methods if the Values being encoded
+//have a value stored at the empty Key, which cannot be represented as a JSON object.
+var ErrRootValue = errors.New("json: cannot encode a value stored at the empty Key")
+
 //LoadString uses l's settings and returns the parsed Values and possible error
 //from decoding in.
 //It is sugar for l.LoadBytes([]byte(in)).
@@ -70,8 +96,8 @@ func (l *Loader) LoadBytes(in []byte) (*config.Values, error) {
 }
 
 //LoadReader uses l's settings and a encoding/json.Decoder to parse Values from in.
-//If the call to encoding/json.Decoder.Decode() returns an error, then that error
-//is returned with nil *Values.
+//If the call to encoding/json.Decoder.Decode() returns an error, then a *ParseError
+//wrapping that error is returned with nil *Values.
 //in must represent a JSON encoded object. Any other type will error.
 //
 //Notice that LoadReader is a config.ReaderFuncLoader and it is used in this manner
@@ -86,27 +112,81 @@ func (l *Loader) LoadReader(in io.Reader) (*config.Values, error) {
 	return values, nil
 }
 
-func (l *Loader) loadMapIntoValues(key config.Key, values *config.Values, object map[string]interface{}) {
+//loadMapIntoValues loads each member of object into values at key appended with
+//the member's name, and returns whether or not anything was loaded.
+func (l *Loader) loadMapIntoValues(key config.Key, values *config.Values, object map[string]interface{}) (loaded bool) {
 	for keyPart, v := range object {
 		if l.KeyPartTransform != nil {
 			keyPart = l.KeyPartTransform(keyPart)
 		}
-		nextKey := key.AppendStrings(keyPart)
-		if m, ok := v.(map[string]interface{}); ok {
-			l.loadMapIntoValues(nextKey, values, m)
-		} else {
-			l.loadSingleIntoValues(nextKey, values, v)
+		loaded = l.loadIntoValues(key.AppendStrings(keyPart), values, v) || loaded
+	}
+	return loaded
+}
+
+//loadIntoValues loads v into values at key and returns whether or not anything
+//was loaded.
+func (l *Loader) loadIntoValues(key config.Key, values *config.Values, v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return l.loadMapIntoValues(key, values, v)
+	case []interface{}:
+		if l.ArraysAsSlices || len(v) == 0 {
+			return l.loadSingleIntoValues(key, values, l.sliceValue(v))
+		}
+		loaded := false
+		for i, elem := range v {
+			loaded = l.loadIntoValues(key.AppendStrings(strconv.Itoa(i)), values, elem) || loaded
 		}
+		return l.loadListIntoValues(key, values, len(v), loaded)
+	default:
+		return l.loadSingleIntoValues(key, values, v)
 	}
 }
 
-func (l *Loader) loadSingleIntoValues(key config.Key, values *config.Values, value interface{}) {
+//loadListIntoValues makes the elements of the array loaded at key a list of
+//length elements with values.PutList(), so that elements that store nothing,
+//such as {} or a discarded null, keep the indices of those that follow dense.
+//The list is stored if anything was loaded within it or if key itself starts
+//with KeyPrefix and ends with KeySuffix, and the result is whether or not it was.
+func (l *Loader) loadListIntoValues(key config.Key, values *config.Values, length int, loaded bool) bool {
+	if !loaded && (!key.StartsWith(l.KeyPrefix) || !key.EndsWith(l.KeySuffix)) {
+		return false
+	}
+	values.PutList(key, length)
+	return true
+}
+
+//sliceValue converts all JSON numbers within s, and within any nested arrays
+//and objects, according to l's settings.
+func (l *Loader) sliceValue(s []interface{}) []interface{} {
+	for i, elem := range s {
+		s[i] = l.nestedValue(elem)
+	}
+	return s
+}
+
+func (l *Loader) nestedValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case jsonlib.Number:
+		return l.numberValue(v)
+	case []interface{}:
+		return l.sliceValue(v)
+	case map[string]interface{}:
+		for keyPart, elem := range v {
+			v[keyPart] = l.nestedValue(elem)
+		}
+	}
+	return v
+}
+
+func (l *Loader) loadSingleIntoValues(key config.Key, values *config.Values, value interface{}) bool {
 	if !key.StartsWith(l.KeyPrefix) || !key.EndsWith(l.KeySuffix) {
-		return
+		return false
 	}
 	if value == nil {
 		if l.DiscardNull {
-			return
+			return false
 		}
 		values.Put(key, nil)
 	}
@@ -116,29 +196,221 @@ func (l *Loader) loadSingleIntoValues(key config.Key, values *config.Values, val
 	default:
 		values.Put(key, v)
 	}
+	return true
 }
 
 func (l *Loader) loadNumberIntoValues(key config.Key, values *config.Values, num jsonlib.Number) {
+	values.Put(key, l.numberValue(num))
+}
+
+func (l *Loader) numberValue(num jsonlib.Number) interface{} {
 	if l.NumberAsString {
-		values.Put(key, num.String())
-		return
+		return num.String()
 	}
 	i64, err := num.Int64()
 	if err != nil {
 		f64, _ := num.Float64()
-		values.Put(key, f64)
-	} else {
-		values.Put(key, i64)
+		return f64
+	}
+	return i64
+}
+
+//EncodeString is sugar for l.EncodeBytes(values) with the result converted to a string.
+func (l *Loader) EncodeString(values *config.Values) (string, error) {
+	out, err := l.EncodeBytes(values)
+	return string(out), err
+}
+
+//EncodeBytes uses l's settings and returns values encoded as a JSON object.
+//It is the inverse of l.LoadBytes().
+//
+//Only values whose Keys start with KeyPrefix and end with KeySuffix are included.
+//nil values are excluded if DiscardNull is true.
+//If NumberAsString is true, then string values that are valid JSON numbers are
+//encoded as JSON numbers.
+//If KeyPartTransform is not nil, then it is called on each Key part and its
+//result is used as the JSON object key.
+//Subtrees keyed by exactly the indices "0" through "n-1" are encoded as JSON arrays.
+//Object keys are sorted, and so the output is deterministic.
+//
+//ErrRootValue is returned if values has a value stored at the empty Key.
+//Any error from encoding/json.Marshal() is returned as is.
+func (l *Loader) EncodeBytes(values *config.Values) ([]byte, error) {
+	object, err := l.encodeObject(values)
+	if err != nil {
+		return nil, err
+	}
+	for keyPart, child := range object {
+		object[keyPart] = collapseArrays(child)
+	}
+	if l.Indent != "" {
+		return jsonlib.MarshalIndent(object, "", l.Indent)
+	}
+	return jsonlib.Marshal(object)
+}
+
+//EncodeWriter writes the result of l.EncodeBytes(values), followed by a newline,
+//to out.
+//It is the inverse of l.LoadReader().
+func (l *Loader) EncodeWriter(out io.Writer, values *config.Values) error {
+	encoded, err := l.EncodeBytes(values)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(encoded, '\n'))
+	return err
+}
+
+//encodeObject returns the nested objects that hold the values in values that
+//match l's settings.
+func (l *Loader) encodeObject(values *config.Values) (map[string]interface{}, error) {
+	var err error
+	object := map[string]interface{}{}
+	values.EachKeyValue(func(key config.Key, value interface{}) {
+		if key.IsEmpty() {
+			err = ErrRootValue
+			return
+		}
+		if !key.StartsWith(l.KeyPrefix) || !key.EndsWith(l.KeySuffix) {
+			return
+		}
+		if value == nil && l.DiscardNull {
+			return
+		}
+		l.putIntoObject(object, key, l.encodeValue(value))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+//collapseArrays returns v with all nested objects whose keys are exactly the
+//indices "0" through "n-1" replaced by JSON arrays.
+func collapseArrays(v interface{}) interface{} {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	for keyPart, child := range object {
+		object[keyPart] = collapseArrays(child)
+	}
+	if len(object) == 0 {
+		return object
+	}
+	indices := make([]int, 0, len(object))
+	for keyPart := range object {
+		index, err := strconv.Atoi(keyPart)
+		if err != nil || strconv.Itoa(index) != keyPart {
+			return object
+		}
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	array := make([]interface{}, len(indices))
+	for i, index := range indices {
+		if index != i {
+			return object
+		}
+		array[i] = object[strconv.Itoa(index)]
+	}
+	return array
+}
+
+func (l *Loader) putIntoObject(object map[string]interface{}, key config.Key, value interface{}) {
+	for i, keyPart := range key {
+		if l.KeyPartTransform != nil {
+			keyPart = l.KeyPartTransform(keyPart)
+		}
+		if i == len(key)-1 {
+			object[keyPart] = value
+			return
+		}
+		child, ok := object[keyPart].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			object[keyPart] = child
+		}
+		object = child
+	}
+}
+
+func (l *Loader) encodeValue(value interface{}) interface{} {
+	if s, ok := value.(string); ok && l.NumberAsString && isNumber(s) {
+		return jsonlib.Number(s)
+	}
+	return value
+}
+
+//isNumber determines whether or not s is exactly a JSON number.
+func isNumber(s string) bool {
+	dec := jsonlib.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var decoded interface{}
+	if err := dec.Decode(&decoded); err != nil || dec.More() {
+		return false
 	}
+	num, ok := decoded.(jsonlib.Number)
+	return ok && num.String() == s
 }
 
 func parseJson(in io.Reader) (map[string]interface{}, error) {
-	dec := jsonlib.NewDecoder(in)
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	dec := jsonlib.NewDecoder(bytes.NewReader(data))
 	dec.UseNumber()
 	result := map[string]interface{}{}
-	err := dec.Decode(&result)
+	err = dec.Decode(&result)
 	if err != nil {
-		return nil, err
+		return nil, newParseError(data, err)
 	}
 	return result, nil
 }
+
+//ParseError describes input that is not a valid JSON object.
+type ParseError struct {
+	//Line and Column are the 1 based position of the offending input.
+	Line   int
+	Column int
+
+	//Offset is the byte offset into the input just past the offending input.
+	Offset int64
+
+	//Err is the underlying error from encoding/json, e.g. an
+	//*encoding/json.SyntaxError or *encoding/json.UnmarshalTypeError, or
+	//io.ErrUnexpectedEOF for truncated input.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("json: line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+//Unwrap returns e.Err.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+//newParseError returns a *ParseError for err, returned from decoding data, that
+//describes the position of the offending input.
+func newParseError(data []byte, err error) *ParseError {
+	offset := int64(len(data))
+	switch err := err.(type) {
+	case *jsonlib.SyntaxError:
+		offset = err.Offset
+	case *jsonlib.UnmarshalTypeError:
+		offset = err.Offset
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	return &ParseError{
+		Line:   bytes.Count(before, []byte("\n")) + 1,
+		Column: len(before) - bytes.LastIndexByte(before, '\n') - 1,
+		Offset: offset,
+		Err:    err,
+	}
+}
@@ -2,6 +2,7 @@ package json
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/gogolfing/config"
@@ -90,3 +91,55 @@ func Example_keyPartTransform() {
 	//value true
 	//false
 }
+
+func ExampleLoader_EncodeWriter() {
+	c := config.New()
+	c.Put("server.host", "localhost")
+	c.Put("server.port", int64(8080))
+	c.Put("debug", true)
+
+	jsonLoader := &Loader{
+		Indent: "\t",
+	}
+	if err := jsonLoader.EncodeWriter(os.Stdout, c.Values()); err != nil {
+		fmt.Println(err)
+	}
+	//Output:
+	//{
+	//	"debug": true,
+	//	"server": {
+	//		"host": "localhost",
+	//		"port": 8080
+	//	}
+	//}
+}
+
+func Example_arrays() {
+	input := `{
+		"servers": [
+			{ "host": "alpha", "port": 8080 },
+			{ "host": "beta", "port": 8081 }
+		]
+	}`
+
+	c := config.New()
+	_, err := c.MergeLoaders(config.NewReaderFuncLoader(
+		(&Loader{}).LoadReader,
+		strings.NewReader(input),
+	))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(c.Len("servers"))
+	fmt.Println(c.GetString("servers.1.host"))
+	for _, server := range c.GetValuesSlice("servers") {
+		fmt.Println(server.Get(config.NewKey("host")), server.Get(config.NewKey("port")))
+	}
+	//Output:
+	//2
+	//beta
+	//alpha 8080
+	//beta 8081
+}
@@ -1,6 +1,10 @@
 package json
 
 import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/gogolfing/config"
@@ -16,6 +20,32 @@ func TestLoader_LoadString_notAnObject(t *testing.T) {
 	}
 }
 
+func TestLoader_LoadString_parseError(t *testing.T) {
+	tests := []struct {
+		in     string
+		line   int
+		column int
+	}{
+		{"{\n\t\"a\": 1,\n\t\"b\" 2\n}", 3, 6},
+		{"[1, 2]", 1, 1},
+		{"{\"a\":", 1, 5},
+	}
+	for _, test := range tests {
+		_, err := (&Loader{}).LoadString(test.in)
+		parseError, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("%q: err = %v WANT *ParseError", test.in, err)
+			continue
+		}
+		if parseError.Line != test.line || parseError.Column != test.column {
+			t.Errorf("%q: line, column = %v, %v WANT %v, %v", test.in, parseError.Line, parseError.Column, test.line, test.column)
+		}
+		if parseError.Unwrap() == nil || !strings.HasPrefix(err.Error(), "json: line ") {
+			t.Errorf("%q: err = %v", test.in, err)
+		}
+	}
+}
+
 func TestLoader_LoadString_keyPrefix(t *testing.T) {
 	in := `{
 		"a": { "b": "b" },
@@ -69,6 +99,246 @@ func TestLoader_LoadString_numberAsString(t *testing.T) {
 	testLoadStringWithWantedValues(t, l, in, want)
 }
 
+func TestLoader_LoadString_arrays(t *testing.T) {
+	in := `{
+		"a": [1, {"b": "b"}, [true, false]],
+		"c": []
+	}`
+	want := config.NewValues()
+	want.Put(config.NewKey("a", "0"), int64(1))
+	want.Put(config.NewKey("a", "1", "b"), "b")
+	want.Put(config.NewKey("a", "2", "0"), true)
+	want.Put(config.NewKey("a", "2", "1"), false)
+
+	v, err := (&Loader{}).LoadString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := v.GetSliceOk(config.NewKey("c"))
+	if !ok || len(c) != 0 {
+		t.Errorf("c = %v, %v WANT empty slice", c, ok)
+	}
+	v.Remove(config.NewKey("c"))
+	if !v.Equal(want) {
+		t.Errorf("v = %v WANT %v", v, want)
+	}
+}
+
+func TestLoader_LoadReader_arraysOverride(t *testing.T) {
+	c := config.New()
+	_, err := c.MergeLoaders(
+		config.NewReaderFuncLoader((&Loader{}).LoadReader, strings.NewReader(`{"a": ["a", "b", "c"], "b": [1, 2]}`)),
+		config.NewReaderFuncLoader((&Loader{}).LoadReader, strings.NewReader(`{"a": ["x"], "b": []}`)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := c.GetSliceOk("a"); !reflect.DeepEqual(a, []interface{}{"x"}) || c.Len("a") != 1 {
+		t.Errorf("a = %v, Len = %v WANT [x], 1", a, c.Len("a"))
+	}
+	if b, _ := c.GetSliceOk("b"); len(b) != 0 || c.Len("b") != 0 {
+		t.Errorf("b = %v, Len = %v WANT [], 0", b, c.Len("b"))
+	}
+
+	c.MergeLoaders(config.NewReaderFuncLoader((&Loader{}).LoadReader, strings.NewReader(`{"a": ["y", "z"]}`)))
+	if a, _ := c.GetSliceOk("a"); !reflect.DeepEqual(a, []interface{}{"y", "z"}) {
+		t.Errorf("a = %v WANT [y z]", a)
+	}
+}
+
+func TestLoader_LoadReader_arraysElementOverride(t *testing.T) {
+	c := config.New()
+	_, err := c.MergeLoaders(
+		config.NewReaderFuncLoader((&Loader{}).LoadReader, strings.NewReader(`{"servers": [{"host": "a", "port": 1}, {"host": "b", "port": 2}]}`)),
+		config.NewReaderFuncLoader((&Loader{}).LoadReader, strings.NewReader(`{"servers": {"0": {"port": 9}}}`)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var servers []struct {
+		Host string
+		Port int
+	}
+	if err := c.Unmarshal("servers", &servers); err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 2 || servers[0].Host != "a" || servers[0].Port != 9 || servers[1].Port != 2 {
+		t.Errorf("servers = %+v WANT [{a 9} {b 2}]", servers)
+	}
+}
+
+func TestLoader_LoadString_arraysEmptyElements(t *testing.T) {
+	in := `{
+		"p": [{}, {"name": "b"}],
+		"q": [null, "b"]
+	}`
+
+	v, err := (&Loader{DiscardNull: true}).LoadString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []config.Key{config.NewKey("p"), config.NewKey("q")} {
+		if length := v.Len(key); length != 2 {
+			t.Errorf("Len(%v) = %v WANT 2", key, length)
+		}
+		if s, ok := v.GetSliceOk(key); !ok || len(s) != 2 {
+			t.Errorf("GetSliceOk(%v) = %v, %v WANT 2 elements", key, s, ok)
+		}
+	}
+
+	var out struct {
+		P []struct{ Name string }
+		Q []string
+	}
+	if err := v.Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.P) != 2 || out.P[1].Name != "b" || !reflect.DeepEqual(out.Q, []string{"", "b"}) {
+		t.Errorf("out = %+v WANT P [{} {b}] and Q [ b]", out)
+	}
+}
+
+func TestLoader_LoadString_arraysKeySuffix(t *testing.T) {
+	in := `{
+		"servers": [{"host": "a", "port": 1}, {"host": "b", "port": 2}]
+	}`
+	l := &Loader{
+		KeySuffix: config.NewKey("host"),
+	}
+	want := config.NewValues()
+	want.Put(config.NewKey("servers", "0", "host"), "a")
+	want.Put(config.NewKey("servers", "1", "host"), "b")
+
+	testLoadStringWithWantedValues(t, l, in, want)
+}
+
+func TestLoader_LoadString_arraysAsSlices(t *testing.T) {
+	in := `{
+		"a": [1, {"b": 2.5}],
+		"c": { "d": [] }
+	}`
+
+	v, err := (&Loader{ArraysAsSlices: true, NumberAsString: true}).LoadString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"1", map[string]interface{}{"b": "2.5"}}
+	if a := v.Get(config.NewKey("a")); !reflect.DeepEqual(a, want) {
+		t.Errorf("a = %#v WANT %#v", a, want)
+	}
+	if d, ok := v.Get(config.NewKey("c", "d")).([]interface{}); !ok || len(d) != 0 {
+		t.Errorf("c.d = %#v WANT empty slice", d)
+	}
+}
+
+func TestLoader_EncodeString_arrays(t *testing.T) {
+	in := `{"a":[1,{"b":"b"},[true,false]],"c":[],"d":{"0":"x","2":"y"}}`
+
+	v, err := (&Loader{}).LoadString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := (&Loader{}).EncodeString(v)
+	if out != in || err != nil {
+		t.Errorf("EncodeString() = %v, %v WANT %v", out, err, in)
+	}
+}
+
+func TestLoader_EncodeString(t *testing.T) {
+	values := config.NewValues()
+	values.Put(config.NewKey("b", "d"), int64(1))
+	values.Put(config.NewKey("b", "c"), "c")
+	values.Put(config.NewKey("a"), nil)
+	values.Put(config.NewKey("e"), []interface{}{true, 1.5})
+
+	tests := []struct {
+		l    *Loader
+		want string
+	}{
+		{&Loader{}, `{"a":null,"b":{"c":"c","d":1},"e":[true,1.5]}`},
+		{&Loader{DiscardNull: true}, `{"b":{"c":"c","d":1},"e":[true,1.5]}`},
+		{&Loader{KeyPrefix: config.NewKey("b")}, `{"b":{"c":"c","d":1}}`},
+		{&Loader{KeySuffix: config.NewKey("c")}, `{"b":{"c":"c"}}`},
+		{&Loader{KeyPartTransform: strings.ToUpper}, `{"A":null,"B":{"C":"c","D":1},"E":[true,1.5]}`},
+		{&Loader{KeyPrefix: config.NewKey("b"), Indent: "  "}, "{\n  \"b\": {\n    \"c\": \"c\",\n    \"d\": 1\n  }\n}"},
+	}
+
+	for i, test := range tests {
+		out, err := test.l.EncodeString(values)
+		if out != test.want || err != nil {
+			t.Errorf("%v: EncodeString() = %v, %v WANT %v", i, out, err, test.want)
+		}
+	}
+}
+
+func TestLoader_EncodeString_numberAsString(t *testing.T) {
+	values := config.NewValues()
+	values.Put(config.NewKey("int"), "12")
+	values.Put(config.NewKey("float"), "-1.5e3")
+	values.Put(config.NewKey("notNumber"), "12 monkeys")
+	values.Put(config.NewKey("hex"), "0x10")
+
+	out, err := (&Loader{NumberAsString: true}).EncodeString(values)
+
+	want := `{"float":-1.5e3,"hex":"0x10","int":12,"notNumber":"12 monkeys"}`
+	if out != want || err != nil {
+		t.Errorf("EncodeString() = %v, %v WANT %v", out, err, want)
+	}
+}
+
+func TestLoader_EncodeString_rootValue(t *testing.T) {
+	values := config.NewValues()
+	values.Put(config.NewKey(), 1)
+
+	if _, err := (&Loader{}).EncodeString(values); err != ErrRootValue {
+		t.Errorf("err = %v WANT %v", err, ErrRootValue)
+	}
+}
+
+func TestLoader_EncodeWriter_roundTrip(t *testing.T) {
+	in := `{
+		"a": { "b": "b", "c": 12, "d": 1.5 },
+		"e": null
+	}`
+	l := &Loader{NumberAsString: true}
+	loaded, err := l.LoadString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	if err := l.EncodeWriter(out, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out.String(), "\n") {
+		t.Errorf("output %q should end with a newline", out.String())
+	}
+
+	reloaded, err := l.LoadReader(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Equal(loaded) {
+		t.Errorf("reloaded %v WANT %v", reloaded, loaded)
+	}
+}
+
+func BenchmarkLoader_LoadString_flat(b *testing.B) {
+	members := make([]string, 10000)
+	for i := range members {
+		members[i] = fmt.Sprintf(`"key%v": %v`, i, i)
+	}
+	in := "{" + strings.Join(members, ",") + "}"
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := (&Loader{}).LoadString(in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func testLoadStringWithWantedValues(t *testing.T, l *Loader, in string, want *config.Values) {
 	v, err := l.LoadString(in)
 	if err != nil {
@@ -0,0 +1,174 @@
+//Package toml provides a Loader type that can be used in conjunction with
+//the parent config package to create a config.Loader to load values from TOML
+//documents.
+package toml
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+
+	tomllib "github.com/BurntSushi/toml"
+	"github.com/gogolfing/config"
+)
+
+//Loader is a collection of settings that can be used with config.NewReaderFuncLoader()
+//or config.NewFileFuncLoader() in order to create a config.Loader that parses
+//TOML documents.
+//Loader itself is not a config.Loader.
+//The empty valued Loader has sane defaults where all key, values found within the
+//TOML are included in the resulting Values.
+//See the individual fields for overriding this behaviour.
+//See the package examples for use with the config package.
+//
+//Tables, including dotted keys and inline tables, become Key paths, e.g. the key
+//port of the table [servers.alpha] is found at [servers alpha port].
+//
+//Values are inserted as strings, int64s, float64s, bools, and time.Times.
+//TOML's local date times, local dates, and local times are time.Times in the
+//system's local offset whose locations are named "datetime-local", "date-local",
+//and "time-local" respectively, and so they can be told apart from offset date
+//times. Local times are on January 1st of year 0.
+type Loader struct {
+	//KeyPrefix is a Key that all Keys found in the TOML must start with in order
+	//to be included in the resulting config.Values.
+	//Notice that an empty KeyPrefix means all Keys are matched.
+	KeyPrefix config.Key
+
+	//KeySuffix is a Key that all Keys found in the TOML must end with in order
+	//to be included in the resulting config.Values.
+	//Notice that an empty KeySuffix means all Keys are matched.
+	KeySuffix config.Key
+
+	//KeyPartTransform is an optional function that is called (if not nil)
+	//on each individual key part found in the TOML. The returned response from
+	//this function is then used to create the resulting Key.
+	KeyPartTransform func(string) string
+
+	//ArraysAsSlices tells Loader whether to insert TOML arrays, and arrays of
+	//tables, as single []interface{} values or to expand them into subtrees keyed
+	//by each element's index, so that elements are reachable by Key, e.g.
+	//[servers 0 host] for the first [[servers]] table.
+	//The zero value means non empty arrays are expanded. Empty arrays are always
+	//inserted as empty []interface{} values.
+	//Tables within arrays inserted as values are map[string]interface{}s.
+	//
+	//Expanded arrays are lists (see config.Values.PutList()), in which empty
+	//tables are empty elements, and which replace each other as a whole when
+	//merged, e.g. by config.Config.MergeLoaders().
+	//
+	//See config.Values.GetSlice() and config.Values.Len() for accessing lists.
+	ArraysAsSlices bool
+}
+
+//LoadString uses l's settings and returns the parsed Values and possible error
+//from decoding in.
+//It is sugar for l.LoadBytes([]byte(in)).
+func (l *Loader) LoadString(in string) (*config.Values, error) {
+	return l.LoadBytes([]byte(in))
+}
+
+//LoadBytes uses l's settings and returns the parsed Values and possible error
+//from decoding in.
+//It is sugar for l.LoadReader(bytes.NewReader(in)).
+func (l *Loader) LoadBytes(in []byte) (*config.Values, error) {
+	return l.LoadReader(bytes.NewReader(in))
+}
+
+//LoadReader uses l's settings and a github.com/BurntSushi/toml.Decoder to parse
+//Values from in.
+//If decoding fails, then the error, which is a github.com/BurntSushi/toml.ParseError
+//describing the offending position for malformed TOML, is returned with nil *Values.
+//
+//Notice that LoadReader is a config.ReaderFuncLoader and it is used in this manner
+//in the examples.
+func (l *Loader) LoadReader(in io.Reader) (*config.Values, error) {
+	table := map[string]interface{}{}
+	if _, err := tomllib.NewDecoder(in).Decode(&table); err != nil {
+		return nil, err
+	}
+	values := config.NewValues()
+	l.loadTableIntoValues(config.Key(nil), values, table)
+	return values, nil
+}
+
+//loadTableIntoValues loads each key of table into values at key appended with
+//the table key, and returns whether or not anything was loaded.
+func (l *Loader) loadTableIntoValues(key config.Key, values *config.Values, table map[string]interface{}) (loaded bool) {
+	for keyPart, v := range table {
+		if l.KeyPartTransform != nil {
+			keyPart = l.KeyPartTransform(keyPart)
+		}
+		loaded = l.loadIntoValues(key.AppendStrings(keyPart), values, v) || loaded
+	}
+	return loaded
+}
+
+//loadIntoValues loads v into values at key and returns whether or not anything
+//was loaded.
+func (l *Loader) loadIntoValues(key config.Key, values *config.Values, v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return l.loadTableIntoValues(key, values, v)
+	case []map[string]interface{}:
+		return l.loadIntoValues(key, values, tablesSlice(v))
+	case []interface{}:
+		if l.ArraysAsSlices || len(v) == 0 {
+			return l.loadSingleIntoValues(key, values, nestedValue(v))
+		}
+		loaded := false
+		for i, elem := range v {
+			loaded = l.loadIntoValues(key.AppendStrings(strconv.Itoa(i)), values, elem) || loaded
+		}
+		return l.loadListIntoValues(key, values, len(v), loaded)
+	default:
+		return l.loadSingleIntoValues(key, values, v)
+	}
+}
+
+func (l *Loader) loadSingleIntoValues(key config.Key, values *config.Values, value interface{}) bool {
+	if !key.StartsWith(l.KeyPrefix) || !key.EndsWith(l.KeySuffix) {
+		return false
+	}
+	values.Put(key, value)
+	return true
+}
+
+//loadListIntoValues makes the elements of the array loaded at key a list of
+//length elements with values.PutList(), so that elements that store nothing,
+//such as an empty [[table]], keep the indices of those that follow dense.
+//The list is stored if anything was loaded within it or if key itself starts
+//with KeyPrefix and ends with KeySuffix, and the result is whether or not it was.
+func (l *Loader) loadListIntoValues(key config.Key, values *config.Values, length int, loaded bool) bool {
+	if !loaded && (!key.StartsWith(l.KeyPrefix) || !key.EndsWith(l.KeySuffix)) {
+		return false
+	}
+	values.PutList(key, length)
+	return true
+}
+
+//nestedValue returns v with all arrays of tables within it, at any depth,
+//converted to []interface{}s, so that all arrays have the same type.
+func nestedValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []map[string]interface{}:
+		return nestedValue(tablesSlice(v))
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = nestedValue(elem)
+		}
+	case map[string]interface{}:
+		for keyPart, elem := range v {
+			v[keyPart] = nestedValue(elem)
+		}
+	}
+	return v
+}
+
+func tablesSlice(tables []map[string]interface{}) []interface{} {
+	result := make([]interface{}, len(tables))
+	for i, table := range tables {
+		result[i] = table
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package toml
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogolfing/config"
+)
+
+func Example() {
+	input := `
+title = "example"
+
+[database]
+ports = [8000, 8001]
+enabled = true
+
+[[servers]]
+host = "alpha"
+
+[[servers]]
+host = "beta"
+`
+
+	type Server struct {
+		Host string
+	}
+	type Settings struct {
+		Title    string
+		Database struct {
+			Ports   []int
+			Enabled bool
+		}
+		Servers []Server
+	}
+
+	loader := config.NewReaderFuncLoader(
+		(&Loader{}).LoadReader,
+		strings.NewReader(input),
+	)
+
+	c := config.New()
+	if _, err := c.MergeLoaders(loader); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	settings := Settings{}
+	if err := c.UnmarshalKey(nil, &settings); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(settings.Title)
+	fmt.Println(settings.Database.Ports, settings.Database.Enabled)
+	fmt.Println(settings.Servers)
+	//Output:
+	//example
+	//[8000 8001] true
+	//[{alpha} {beta}]
+}
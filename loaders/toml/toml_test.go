@@ -0,0 +1,216 @@
+package toml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	tomllib "github.com/BurntSushi/toml"
+	"github.com/gogolfing/config"
+)
+
+func TestLoader_LoadString_values(t *testing.T) {
+	in := `
+string = "foo bar"
+literal = 'C:\path'
+int = 12345678
+hex = 0x10
+float = 1234.5678
+bool = true
+`
+	want := config.NewValues()
+	want.Put(config.NewKey("string"), "foo bar")
+	want.Put(config.NewKey("literal"), `C:\path`)
+	want.Put(config.NewKey("int"), int64(12345678))
+	want.Put(config.NewKey("hex"), int64(16))
+	want.Put(config.NewKey("float"), 1234.5678)
+	want.Put(config.NewKey("bool"), true)
+	testLoadStringWithWantedValues(t, &Loader{}, in, want)
+}
+
+func TestLoader_LoadString_datetimes(t *testing.T) {
+	in := `
+offset = 1979-05-27T07:32:00-07:00
+local = 1979-05-27T07:32:00
+date = 1979-05-27
+time = 07:32:00
+`
+	v, err := (&Loader{}).LoadString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key      string
+		want     string
+		location string
+	}{
+		{"offset", "1979-05-27T07:32:00-07:00", ""},
+		{"local", "1979-05-27T07:32:00", "datetime-local"},
+		{"date", "1979-05-27T00:00:00", "date-local"},
+		{"time", "0000-01-01T07:32:00", "time-local"},
+	}
+	for _, test := range tests {
+		got, ok := v.Get(config.NewKey(test.key)).(time.Time)
+		if !ok {
+			t.Errorf("%v = %#v WANT time.Time", test.key, v.Get(config.NewKey(test.key)))
+			continue
+		}
+		layout := "2006-01-02T15:04:05"
+		if test.location == "" {
+			layout = time.RFC3339
+		}
+		if formatted := got.Format(layout); formatted != test.want {
+			t.Errorf("%v = %v WANT %v", test.key, formatted, test.want)
+		}
+		if test.location != "" && got.Location().String() != test.location {
+			t.Errorf("%v location = %v WANT %v", test.key, got.Location(), test.location)
+		}
+	}
+}
+
+func TestLoader_LoadString_tables(t *testing.T) {
+	in := `
+title = "example"
+owner.name = "Tom"
+inline = {a = 1, b.c = 2}
+
+[database]
+server = "192.168.1.1"
+
+[servers.alpha]
+ip = "10.0.0.1"
+"quoted.key" = true
+`
+	want := config.NewValues()
+	want.Put(config.NewKey("title"), "example")
+	want.Put(config.NewKey("owner", "name"), "Tom")
+	want.Put(config.NewKey("inline", "a"), int64(1))
+	want.Put(config.NewKey("inline", "b", "c"), int64(2))
+	want.Put(config.NewKey("database", "server"), "192.168.1.1")
+	want.Put(config.NewKey("servers", "alpha", "ip"), "10.0.0.1")
+	want.Put(config.NewKey("servers", "alpha", "quoted.key"), true)
+	testLoadStringWithWantedValues(t, &Loader{}, in, want)
+}
+
+const arraysInput = `
+ports = [8000, 8001]
+empty = []
+mixed = [[1, 2], {a = "b"}]
+
+[[products]]
+name = "Hammer"
+
+[[products]]
+
+[[products]]
+name = "Nail"
+[[products.variants]]
+color = "grey"
+`
+
+func TestLoader_LoadString_arrays(t *testing.T) {
+	want := config.NewValues()
+	want.Put(config.NewKey("ports", "0"), int64(8000))
+	want.Put(config.NewKey("ports", "1"), int64(8001))
+	want.Put(config.NewKey("empty"), []interface{}{})
+	want.Put(config.NewKey("mixed", "0", "0"), int64(1))
+	want.Put(config.NewKey("mixed", "0", "1"), int64(2))
+	want.Put(config.NewKey("mixed", "1", "a"), "b")
+	want.PutList(config.NewKey("products"), 3)
+	want.Put(config.NewKey("products", "0", "name"), "Hammer")
+	want.Put(config.NewKey("products", "2", "name"), "Nail")
+	want.Put(config.NewKey("products", "2", "variants", "0", "color"), "grey")
+	testLoadStringWithWantedValues(t, &Loader{}, arraysInput, want)
+
+	v, err := (&Loader{}).LoadString(arraysInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if length := v.Len(config.NewKey("products")); length != 3 {
+		t.Errorf("Len(products) = %v WANT 3", length)
+	}
+	var products []struct {
+		Name     string
+		Variants []struct{ Color string }
+	}
+	if err := v.DecodeKey(config.NewKey("products"), &products); err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 3 || products[1].Name != "" || products[2].Name != "Nail" || products[2].Variants[0].Color != "grey" {
+		t.Errorf("products = %+v WANT Hammer, empty, and Nail", products)
+	}
+}
+
+func TestLoader_LoadString_arraysAsSlices(t *testing.T) {
+	v, err := (&Loader{ArraysAsSlices: true}).LoadString(arraysInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"ports": []interface{}{int64(8000), int64(8001)},
+		"empty": []interface{}{},
+		"mixed": []interface{}{
+			[]interface{}{int64(1), int64(2)},
+			map[string]interface{}{"a": "b"},
+		},
+		"products": []interface{}{
+			map[string]interface{}{"name": "Hammer"},
+			map[string]interface{}{},
+			map[string]interface{}{
+				"name": "Nail",
+				"variants": []interface{}{
+					map[string]interface{}{"color": "grey"},
+				},
+			},
+		},
+	}
+	for key, value := range want {
+		if got := v.Get(config.NewKey(key)); !reflect.DeepEqual(got, value) {
+			t.Errorf("%v = %#v WANT %#v", key, got, value)
+		}
+	}
+}
+
+func TestLoader_LoadString_settings(t *testing.T) {
+	in := `
+[A.B]
+C = "c"
+[D]
+C = "d"
+`
+	l := &Loader{
+		KeyPrefix:        config.NewKey("a"),
+		KeySuffix:        config.NewKey("c"),
+		KeyPartTransform: func(k string) string { return string(k[0] + 'a' - 'A') },
+	}
+	want := config.NewValues()
+	want.Put(config.NewKey("a", "b", "c"), "c")
+	testLoadStringWithWantedValues(t, l, in, want)
+}
+
+func TestLoader_LoadString_empty(t *testing.T) {
+	testLoadStringWithWantedValues(t, &Loader{}, "", config.NewValues())
+	testLoadStringWithWantedValues(t, &Loader{}, "# comment only", config.NewValues())
+}
+
+func TestLoader_LoadString_error(t *testing.T) {
+	v, err := (&Loader{}).LoadString("a = 1\nb = ?\n")
+	if v != nil {
+		t.Errorf("v = %v WANT nil", v)
+	}
+	var parseError tomllib.ParseError
+	if !errors.As(err, &parseError) || parseError.Position.Line != 2 {
+		t.Errorf("err = %#v WANT toml.ParseError on line 2", err)
+	}
+}
+
+func testLoadStringWithWantedValues(t *testing.T, l *Loader, in string, want *config.Values) {
+	v, err := l.LoadString(in)
+	if err != nil {
+		t.Error(err)
+	}
+	if !v.Equal(want) {
+		t.Fail()
+	}
+}
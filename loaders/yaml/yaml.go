@@ -0,0 +1,483 @@
+//Package yaml provides a Loader type that can be used in conjunction with
+//the parent config package to create a config.Loader to load values from YAML
+//documents.
+package yaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gogolfing/config"
+	yamllib "gopkg.in/yaml.v3"
+)
+
+//Loader is a collection of settings that can be used with config.NewReaderFuncLoader()
+//in order to create a config.Loader that parses YAML documents.
+//Loader itself is not a config.Loader.
+//The empty valued Loader has sane defaults where all key, values found within the YAML
+//are included in the resulting Values and YAML nulls are inserted as nil.
+//See the individual fields for overriding this behaviour.
+//See the package examples for use with the config package.
+//
+//Each document must be a mapping, or empty. The documents of a multi document
+//stream are merged into the resulting Values in order (see config.Values.Merge),
+//and so later documents override earlier ones: mappings are merged key by key,
+//while sequences and empty mappings replace whatever earlier documents stored
+//at their keys.
+//
+//	a: [1, 2, 3]
+//	b: {c: 1}
+//	---
+//	a: [9]    # a is [9]
+//	b: {}     # b.c is removed
+//
+//Mapping keys that are not strings, such as ints and bools, are used as their
+//YAML text, e.g. the key 80 in {80: http} becomes the Key part "80".
+//Anchors and aliases are expanded, up to a limit per document (see
+//ErrExcessiveAliasing), and merge keys ("<<") are applied with the explicit keys
+//of a mapping overriding the merged ones.
+//
+//Scalars are inserted as nil, bools, int64s (uint64s if they are too large),
+//float64s, time.Times for timestamps, and strings for everything else.
+type Loader struct {
+	//KeyPrefix is a Key that all Keys found in the YAML must start with in order
+	//to be included in the resulting config.Values.
+	//Notice that an empty KeyPrefix means all Keys are matched.
+	KeyPrefix config.Key
+
+	//KeySuffix is a Key that all Keys found in the YAML must end with in order
+	//to be included in the resulting config.Values.
+	//Notice that an empty KeySuffix means all Keys are matched.
+	KeySuffix config.Key
+
+	//DiscardNull tells Loader whether or not to include YAML nulls as nil
+	//in the resulting Values.
+	//The zero value means all nulls are indeed included.
+	DiscardNull bool
+
+	//KeyPartTransform is an optional function that is called (if not nil)
+	//on each individual key part found in the YAML. The returned response from
+	//this function is then used to create the resulting Key.
+	KeyPartTransform func(string) string
+
+	//ArraysAsSlices tells Loader whether to insert YAML sequences as single
+	//[]interface{} values or to expand them into subtrees keyed by each element's
+	//index, so that elements are reachable by Key, e.g. [servers 0 host].
+	//The zero value means non empty sequences are expanded. Empty sequences are
+	//always inserted as empty []interface{} values.
+	//Mappings within sequences inserted as values are map[string]interface{}s.
+	//
+	//Expanded sequences are lists (see config.Values.PutList()), in which empty
+	//mappings and discarded nulls are empty elements.
+	ArraysAsSlices bool
+}
+
+//ParseError describes YAML that cannot be loaded, either because it is malformed
+//or because it is well formed but, e.g., a document is not a mapping.
+type ParseError struct {
+	//Line and Column are the 1 based position of the offending node.
+	//For malformed YAML, Column is 0, and so is Line if gopkg.in/yaml.v3 does
+	//not report it.
+	Line   int
+	Column int
+
+	//Err describes the problem. For malformed YAML it is the message of the
+	//gopkg.in/yaml.v3 error without its position.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("yaml: line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+//Unwrap returns e.Err.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+//ErrNotMapping is the Err of a *ParseError for a document that is not a mapping.
+var ErrNotMapping = errors.New("document is not a mapping")
+
+//ErrInvalidKey is the Err of a *ParseError for a mapping key that is not a scalar.
+var ErrInvalidKey = errors.New("mapping key is not a scalar")
+
+//ErrDuplicateKey is the Err of a *ParseError for a mapping key that appears more
+//than once in the same mapping.
+var ErrDuplicateKey = errors.New("mapping key is duplicated")
+
+//ErrInvalidMerge is the Err of a *ParseError for a merge key ("<<") whose value
+//is not a mapping or a sequence of mappings.
+var ErrInvalidMerge = errors.New("merge value is not a mapping or sequence of mappings")
+
+//ErrRecursiveAlias is the Err of a *ParseError for an alias that refers to a
+//node that contains it.
+var ErrRecursiveAlias = errors.New("alias refers to a node that contains it")
+
+//ErrExcessiveAliasing is the Err of a *ParseError for a document whose aliases
+//expand to more than 100000 nodes altogether, e.g. aliases of sequences of
+//aliases that grow exponentially with each level of nesting.
+//The Line and Column of the *ParseError are those of the outermost alias being
+//expanded.
+var ErrExcessiveAliasing = errors.New("aliases expand to too many nodes")
+
+//LoadString uses l's settings and returns the parsed Values and possible error
+//from decoding in.
+//It is sugar for l.LoadBytes([]byte(in)).
+func (l *Loader) LoadString(in string) (*config.Values, error) {
+	return l.LoadBytes([]byte(in))
+}
+
+//LoadBytes uses l's settings and returns the parsed Values and possible error
+//from decoding in.
+//It is sugar for l.LoadReader(bytes.NewReader(in)).
+func (l *Loader) LoadBytes(in []byte) (*config.Values, error) {
+	return l.LoadReader(bytes.NewReader(in))
+}
+
+//LoadReader uses l's settings and a gopkg.in/yaml.v3 Decoder to parse Values
+//from all documents in in.
+//If any error occurs, then it is returned, as a *ParseError, with nil *Values.
+//
+//Notice that LoadReader is a config.ReaderFuncLoader and it is used in this manner
+//in the examples.
+func (l *Loader) LoadReader(in io.Reader) (*config.Values, error) {
+	decoder := yamllib.NewDecoder(in)
+	values := config.NewValues()
+	for {
+		node := &yamllib.Node{}
+		err := decoder.Decode(node)
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, newSyntaxError(err)
+		}
+		d := &document{values: config.NewValues()}
+		if err := l.loadDocument(d, node); err != nil {
+			return nil, err
+		}
+		for _, key := range d.emptied {
+			values.Remove(key)
+		}
+		values.Merge(config.Key(nil), d.values)
+	}
+}
+
+//document holds the values loaded from a single YAML document along with the
+//Keys of its empty mappings, which remove the values of earlier documents.
+type document struct {
+	values  *config.Values
+	emptied []config.Key
+
+	//expanded is the number of nodes visited within aliases.
+	expanded int
+}
+
+//maxAliasExpansion is the number of nodes that the aliases of a single document
+//may expand to. See ErrExcessiveAliasing.
+const maxAliasExpansion = 100000
+
+func (l *Loader) loadDocument(d *document, node *yamllib.Node) error {
+	if len(node.Content) == 0 {
+		return nil
+	}
+	root, err := d.resolve(node.Content[0], nil)
+	if err != nil {
+		return err
+	}
+	if root.ShortTag() == "!!null" {
+		return nil
+	}
+	if root.Kind != yamllib.MappingNode {
+		return newParseError(root, ErrNotMapping)
+	}
+	_, err = l.loadIntoValues(config.Key(nil), d, root, nil)
+	return err
+}
+
+//loadIntoValues loads the resolved node n into d at key and returns whether or
+//not anything was loaded.
+//aliases holds the alias nodes being expanded.
+func (l *Loader) loadIntoValues(key config.Key, d *document, n *yamllib.Node, aliases []*yamllib.Node) (loaded bool, err error) {
+	switch n.Kind {
+	case yamllib.MappingNode:
+		entries, err := l.mappingEntries(d, n, aliases)
+		if err != nil {
+			return false, err
+		}
+		if len(entries) == 0 && len(key) > 0 {
+			d.emptied = append(d.emptied, key)
+		}
+		for _, e := range entries {
+			entryLoaded, err := l.loadIntoValues(key.AppendStrings(e.keyPart), d, e.value, e.aliases)
+			if err != nil {
+				return false, err
+			}
+			loaded = entryLoaded || loaded
+		}
+		return loaded, nil
+	case yamllib.SequenceNode:
+		if l.ArraysAsSlices || len(n.Content) == 0 {
+			value, err := l.nestedValue(d, n, aliases)
+			if err != nil {
+				return false, err
+			}
+			return l.loadSingleIntoValues(key, d.values, value), nil
+		}
+		for i, elem := range n.Content {
+			elem, elemAliases, err := d.resolveAlias(elem, aliases)
+			if err != nil {
+				return false, err
+			}
+			elemLoaded, err := l.loadIntoValues(key.AppendStrings(strconv.Itoa(i)), d, elem, elemAliases)
+			if err != nil {
+				return false, err
+			}
+			loaded = elemLoaded || loaded
+		}
+		return l.loadListIntoValues(key, d.values, len(n.Content), loaded), nil
+	default:
+		value, err := scalarValue(n)
+		if err != nil {
+			return false, err
+		}
+		return l.loadSingleIntoValues(key, d.values, value), nil
+	}
+}
+
+func (l *Loader) loadSingleIntoValues(key config.Key, values *config.Values, value interface{}) bool {
+	if !key.StartsWith(l.KeyPrefix) || !key.EndsWith(l.KeySuffix) {
+		return false
+	}
+	if value == nil && l.DiscardNull {
+		return false
+	}
+	values.Put(key, value)
+	return true
+}
+
+//loadListIntoValues makes the elements of the sequence loaded at key a list of
+//length elements with values.PutList(), so that elements that store nothing,
+//such as {} or a discarded null, keep the indices of those that follow dense.
+//The list is stored if anything was loaded within it or if key itself starts
+//with KeyPrefix and ends with KeySuffix, and the result is whether or not it was.
+func (l *Loader) loadListIntoValues(key config.Key, values *config.Values, length int, loaded bool) bool {
+	if !loaded && (!key.StartsWith(l.KeyPrefix) || !key.EndsWith(l.KeySuffix)) {
+		return false
+	}
+	values.PutList(key, length)
+	return true
+}
+
+//nestedValue returns the resolved node n as a single value, with mappings as
+//map[string]interface{}s and sequences as []interface{}s.
+func (l *Loader) nestedValue(d *document, n *yamllib.Node, aliases []*yamllib.Node) (interface{}, error) {
+	switch n.Kind {
+	case yamllib.MappingNode:
+		entries, err := l.mappingEntries(d, n, aliases)
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[string]interface{}, len(entries))
+		for _, e := range entries {
+			if result[e.keyPart], err = l.nestedValue(d, e.value, e.aliases); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case yamllib.SequenceNode:
+		result := make([]interface{}, len(n.Content))
+		for i, elem := range n.Content {
+			elem, elemAliases, err := d.resolveAlias(elem, aliases)
+			if err != nil {
+				return nil, err
+			}
+			if result[i], err = l.nestedValue(d, elem, elemAliases); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+	return scalarValue(n)
+}
+
+//entry is a single resolved key, value pair of a mapping.
+type entry struct {
+	keyPart string
+	value   *yamllib.Node
+	aliases []*yamllib.Node
+}
+
+//mappingEntries returns the entries of the mapping n in order, with the entries
+//of merged mappings first and overridden by n's explicit keys.
+//A *ParseError is returned if n has the same explicit key more than once.
+func (l *Loader) mappingEntries(d *document, n *yamllib.Node, aliases []*yamllib.Node) ([]entry, error) {
+	explicit := []entry{}
+	merged := []entry{}
+	seen := map[string]bool{}
+	keys := map[string]bool{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		keyNode, valueNode := n.Content[i], n.Content[i+1]
+		keyNode, _, err := d.resolveAlias(keyNode, aliases)
+		if err != nil {
+			return nil, err
+		}
+		valueNode, valueAliases, err := d.resolveAlias(valueNode, aliases)
+		if err != nil {
+			return nil, err
+		}
+		if keyNode.Kind != yamllib.ScalarNode {
+			return nil, newParseError(keyNode, ErrInvalidKey)
+		}
+		if keyNode.ShortTag() == "!!merge" {
+			entries, err := l.mergeEntries(d, valueNode, valueAliases)
+			if err != nil {
+				return nil, err
+			}
+			merged = append(merged, entries...)
+			continue
+		}
+		if keys[keyNode.Value] {
+			return nil, newParseError(keyNode, ErrDuplicateKey)
+		}
+		keys[keyNode.Value] = true
+		keyPart := keyNode.Value
+		if l.KeyPartTransform != nil {
+			keyPart = l.KeyPartTransform(keyPart)
+		}
+		seen[keyPart] = true
+		explicit = append(explicit, entry{keyPart, valueNode, valueAliases})
+	}
+
+	result := []entry{}
+	for _, e := range merged {
+		if !seen[e.keyPart] {
+			seen[e.keyPart] = true
+			result = append(result, e)
+		}
+	}
+	return append(result, explicit...), nil
+}
+
+//mergeEntries returns the entries merged by the merge key value n, which is a
+//mapping or a sequence of mappings where earlier mappings take precedence.
+func (l *Loader) mergeEntries(d *document, n *yamllib.Node, aliases []*yamllib.Node) ([]entry, error) {
+	if n.Kind == yamllib.MappingNode {
+		return l.mappingEntries(d, n, aliases)
+	}
+	if n.Kind != yamllib.SequenceNode {
+		return nil, newParseError(n, ErrInvalidMerge)
+	}
+	result := []entry{}
+	seen := map[string]bool{}
+	for _, elem := range n.Content {
+		elem, elemAliases, err := d.resolveAlias(elem, aliases)
+		if err != nil {
+			return nil, err
+		}
+		if elem.Kind != yamllib.MappingNode {
+			return nil, newParseError(elem, ErrInvalidMerge)
+		}
+		entries, err := l.mappingEntries(d, elem, elemAliases)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !seen[e.keyPart] {
+				seen[e.keyPart] = true
+				result = append(result, e)
+			}
+		}
+	}
+	return result, nil
+}
+
+//resolve is d.resolveAlias(n, aliases) without the resulting aliases.
+func (d *document) resolve(n *yamllib.Node, aliases []*yamllib.Node) (*yamllib.Node, error) {
+	n, _, err := d.resolveAlias(n, aliases)
+	return n, err
+}
+
+//resolveAlias returns the node that n refers to if it is an alias, and n
+//otherwise, along with the alias nodes being expanded within the result.
+//A *ParseError is returned if n is an alias that is already being expanded, or
+//if the result is within an alias and d has expanded maxAliasExpansion nodes.
+func (d *document) resolveAlias(n *yamllib.Node, aliases []*yamllib.Node) (*yamllib.Node, []*yamllib.Node, error) {
+	for n.Kind == yamllib.AliasNode {
+		for _, alias := range aliases {
+			if alias.Alias == n.Alias {
+				return nil, nil, newParseError(n, ErrRecursiveAlias)
+			}
+		}
+		aliases = append(aliases[:len(aliases):len(aliases)], n)
+		n = n.Alias
+	}
+	if len(aliases) > 0 {
+		if d.expanded >= maxAliasExpansion {
+			return nil, nil, newParseError(aliases[0], ErrExcessiveAliasing)
+		}
+		d.expanded++
+	}
+	return n, aliases, nil
+}
+
+//scalarValue returns the value of the scalar n according to its tag.
+func scalarValue(n *yamllib.Node) (interface{}, error) {
+	if n.ShortTag() == "!!timestamp" {
+		var t time.Time
+		if err := n.Decode(&t); err != nil {
+			return nil, newParseError(n, trimError(err))
+		}
+		return t, nil
+	}
+	var value interface{}
+	if err := n.Decode(&value); err != nil {
+		return nil, newParseError(n, trimError(err))
+	}
+	if i, ok := value.(int); ok {
+		return int64(i), nil
+	}
+	return value, nil
+}
+
+func newParseError(n *yamllib.Node, err error) *ParseError {
+	return &ParseError{
+		Line:   n.Line,
+		Column: n.Column,
+		Err:    err,
+	}
+}
+
+//errorPosition matches the prefix and position that gopkg.in/yaml.v3 adds to
+//the messages of its errors.
+var errorPosition = regexp.MustCompile(`^yaml: (?:line (\d+): )?`)
+
+//newSyntaxError returns a *ParseError for err, returned from decoding malformed
+//YAML, with the line in err's message as its Line.
+func newSyntaxError(err error) *ParseError {
+	match := errorPosition.FindStringSubmatch(err.Error())
+	line := 0
+	if match != nil {
+		line, _ = strconv.Atoi(match[1])
+	}
+	return &ParseError{
+		Line: line,
+		Err:  trimError(err),
+	}
+}
+
+//trimError returns an error with the message of the gopkg.in/yaml.v3 error err
+//without its prefix and position, or err if it has neither.
+func trimError(err error) error {
+	message := err.Error()
+	match := errorPosition.FindStringIndex(message)
+	if match == nil {
+		return err
+	}
+	return errors.New(message[match[1]:])
+}
@@ -0,0 +1,41 @@
+package yaml
+
+import (
+	"fmt"
+
+	"github.com/gogolfing/config"
+)
+
+func Example() {
+	input := `
+defaults: &defaults
+  host: localhost
+  timeout: 1.5
+
+primary:
+  <<: *defaults
+  port: 5432
+
+replicas: [replica1, replica2]
+---
+primary:
+  host: db.example.com
+replicas: [replica3]
+`
+
+	values, err := (&Loader{ArraysAsSlices: true}).LoadString(input)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(values.Get(config.NewKey("primary", "host")))
+	fmt.Println(values.Get(config.NewKey("primary", "port")))
+	fmt.Println(values.Get(config.NewKey("primary", "timeout")))
+	fmt.Println(values.Get(config.NewKey("replicas")))
+	//Output:
+	//db.example.com
+	//5432
+	//1.5
+	//[replica3]
+}
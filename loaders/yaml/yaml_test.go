@@ -0,0 +1,282 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogolfing/config"
+)
+
+func TestLoader_LoadString_scalars(t *testing.T) {
+	in := `
+string: foo bar
+quoted: "123"
+bool: true
+int: 12345678
+hex: 0x10
+big: 18446744073709551615
+float: 1234.5678
+null: ~
+timestamp: 2001-12-14t21:59:43.10-05:00
+date: 2002-12-14
+`
+	want := config.NewValues()
+	want.Put(config.NewKey("string"), "foo bar")
+	want.Put(config.NewKey("quoted"), "123")
+	want.Put(config.NewKey("bool"), true)
+	want.Put(config.NewKey("int"), int64(12345678))
+	want.Put(config.NewKey("hex"), int64(16))
+	want.Put(config.NewKey("big"), uint64(18446744073709551615))
+	want.Put(config.NewKey("float"), 1234.5678)
+	want.Put(config.NewKey("null"), nil)
+	want.Put(config.NewKey("timestamp"), time.Date(2001, 12, 14, 21, 59, 43, 100000000, time.FixedZone("", -5*60*60)))
+	want.Put(config.NewKey("date"), time.Date(2002, 12, 14, 0, 0, 0, 0, time.UTC))
+	testLoadStringWithWantedValues(t, &Loader{}, in, want)
+}
+
+func TestLoader_LoadString_timestampEqual(t *testing.T) {
+	v, err := (&Loader{}).LoadString("t: 2001-12-14t21:59:43.10-05:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2001, 12, 15, 2, 59, 43, 100000000, time.UTC)
+	if got, ok := v.Get(config.NewKey("t")).(time.Time); !ok || !got.Equal(want) {
+		t.Errorf("t = %v WANT %v", got, want)
+	}
+}
+
+func TestLoader_LoadString_nonStringKeys(t *testing.T) {
+	want := config.NewValues()
+	want.Put(config.NewKey("ports", "80"), "http")
+	want.Put(config.NewKey("ports", "443"), "https")
+	want.Put(config.NewKey("true"), "yes")
+	want.Put(config.NewKey("1.5"), "float")
+	testLoadStringWithWantedValues(t, &Loader{}, "ports: {80: http, 443: https}\ntrue: yes\n1.5: float", want)
+}
+
+func TestLoader_LoadString_aliasesAndMerges(t *testing.T) {
+	in := `
+defaults: &defaults
+  host: localhost
+  port: 80
+  tls: {enabled: false}
+web:
+  <<: *defaults
+  port: 8080
+multi:
+  <<: [{a: 1, b: 1}, {a: 2, c: 2}]
+  b: 3
+hosts: [*defaults]
+copy: *defaults
+`
+	want := config.NewValues()
+	want.Put(config.NewKey("defaults", "host"), "localhost")
+	want.Put(config.NewKey("defaults", "port"), int64(80))
+	want.Put(config.NewKey("defaults", "tls", "enabled"), false)
+	want.Put(config.NewKey("web", "host"), "localhost")
+	want.Put(config.NewKey("web", "port"), int64(8080))
+	want.Put(config.NewKey("web", "tls", "enabled"), false)
+	want.Put(config.NewKey("multi", "a"), int64(1))
+	want.Put(config.NewKey("multi", "b"), int64(3))
+	want.Put(config.NewKey("multi", "c"), int64(2))
+	want.Put(config.NewKey("hosts", "0", "host"), "localhost")
+	want.Put(config.NewKey("hosts", "0", "port"), int64(80))
+	want.Put(config.NewKey("hosts", "0", "tls", "enabled"), false)
+	want.Put(config.NewKey("copy", "host"), "localhost")
+	want.Put(config.NewKey("copy", "port"), int64(80))
+	want.Put(config.NewKey("copy", "tls", "enabled"), false)
+	testLoadStringWithWantedValues(t, &Loader{}, in, want)
+}
+
+func TestLoader_LoadString_multipleDocuments(t *testing.T) {
+	in := `
+a: 1
+b: {c: 1, d: 1}
+---
+---
+b: {c: 2}
+e: 2
+`
+	want := config.NewValues()
+	want.Put(config.NewKey("a"), int64(1))
+	want.Put(config.NewKey("b", "c"), int64(2))
+	want.Put(config.NewKey("b", "d"), int64(1))
+	want.Put(config.NewKey("e"), int64(2))
+	testLoadStringWithWantedValues(t, &Loader{}, in, want)
+}
+
+func TestLoader_LoadString_multipleDocumentsReplace(t *testing.T) {
+	in := `
+a: [1, 2, 3]
+b: {c: 1}
+d: {e: [1, 2]}
+---
+a: [9]
+b: {}
+d: {e: []}
+`
+	want := config.NewValues()
+	want.Put(config.NewKey("a", "0"), int64(9))
+	want.Put(config.NewKey("d", "e"), []interface{}{})
+	testLoadStringWithWantedValues(t, &Loader{}, in, want)
+	want = config.NewValues()
+	want.Put(config.NewKey("a"), []interface{}{int64(9)})
+	want.Put(config.NewKey("d", "e"), []interface{}{})
+	testLoadStringWithWantedValues(t, &Loader{ArraysAsSlices: true}, in, want)
+}
+
+func TestLoader_LoadString_sequences(t *testing.T) {
+	in := "a: [1, {b: 2}]\nempty: []"
+	want := config.NewValues()
+	want.Put(config.NewKey("a", "0"), int64(1))
+	want.Put(config.NewKey("a", "1", "b"), int64(2))
+	want.Put(config.NewKey("empty"), []interface{}{})
+	testLoadStringWithWantedValues(t, &Loader{}, in, want)
+
+	v, err := (&Loader{ArraysAsSlices: true}).LoadString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantA := []interface{}{int64(1), map[string]interface{}{"b": int64(2)}}
+	if a := v.Get(config.NewKey("a")); !reflect.DeepEqual(a, wantA) {
+		t.Errorf("a = %#v WANT %#v", a, wantA)
+	}
+}
+
+func TestLoader_LoadString_sequencesEmptyElements(t *testing.T) {
+	in := "p: [{}, {name: b}]\nq: [~, b]"
+	want := config.NewValues()
+	want.PutList(config.NewKey("p"), 2)
+	want.Put(config.NewKey("p", "1", "name"), "b")
+	want.PutList(config.NewKey("q"), 2)
+	want.Put(config.NewKey("q", "1"), "b")
+	testLoadStringWithWantedValues(t, &Loader{DiscardNull: true}, in, want)
+
+	v, err := (&Loader{DiscardNull: true}).LoadString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []config.Key{config.NewKey("p"), config.NewKey("q")} {
+		if length := v.Len(key); length != 2 {
+			t.Errorf("Len(%v) = %v WANT 2", key, length)
+		}
+	}
+	var out struct {
+		P []struct{ Name string }
+		Q []string
+	}
+	if err := v.Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.P) != 2 || out.P[1].Name != "b" || !reflect.DeepEqual(out.Q, []string{"", "b"}) {
+		t.Errorf("out = %+v WANT P [{} {b}] and Q [ b]", out)
+	}
+}
+
+func TestLoader_LoadString_settings(t *testing.T) {
+	in := `
+A: {B: {C: c}, N: ~}
+D: {C: d}
+`
+	l := &Loader{
+		KeyPrefix:        config.NewKey("a"),
+		KeySuffix:        config.NewKey("c"),
+		DiscardNull:      true,
+		KeyPartTransform: func(k string) string { return string(k[0] + 'a' - 'A') },
+	}
+	want := config.NewValues()
+	want.Put(config.NewKey("a", "b", "c"), "c")
+	testLoadStringWithWantedValues(t, l, in, want)
+
+	l = &Loader{KeyPrefix: config.NewKey("A")}
+	want = config.NewValues()
+	want.Put(config.NewKey("A", "B", "C"), "c")
+	want.Put(config.NewKey("A", "N"), nil)
+	testLoadStringWithWantedValues(t, l, in, want)
+}
+
+func TestLoader_LoadString_excessiveAliasing(t *testing.T) {
+	nested := func(levels int) string {
+		lines := []string{"a0: &a0 [x, x, x, x, x, x, x, x, x, x]"}
+		for i := 1; i < levels; i++ {
+			alias := fmt.Sprintf("*a%v", i-1)
+			elems := strings.TrimSuffix(strings.Repeat(alias+", ", 10), ", ")
+			lines = append(lines, fmt.Sprintf("a%v: &a%v [%v]", i, i, elems))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	v, err := (&Loader{}).LoadString(nested(4))
+	if err != nil || v.Len(config.NewKey("a3")) != 10 {
+		t.Errorf("LoadString(4 levels) = %v WANT 10 elements at a3", err)
+	}
+
+	for _, l := range []*Loader{{}, {ArraysAsSlices: true}} {
+		v, err := l.LoadString(nested(8))
+		parseError, ok := err.(*ParseError)
+		if v != nil || !ok || parseError.Err != ErrExcessiveAliasing || parseError.Line != 5 {
+			t.Errorf("ArraysAsSlices %v: LoadString(8 levels) = %v, %#v WANT *ParseError at line 5", l.ArraysAsSlices, v, err)
+		}
+	}
+}
+
+func TestLoader_LoadString_empty(t *testing.T) {
+	testLoadStringWithWantedValues(t, &Loader{}, "", config.NewValues())
+	testLoadStringWithWantedValues(t, &Loader{}, "~", config.NewValues())
+	testLoadStringWithWantedValues(t, &Loader{}, "# comment only", config.NewValues())
+}
+
+func TestLoader_LoadString_errors(t *testing.T) {
+	tests := []struct {
+		in     string
+		err    error
+		line   int
+		column int
+	}{
+		{"- 1", ErrNotMapping, 1, 1},
+		{"a: 1\n---\nfoo", ErrNotMapping, 3, 1},
+		{"? [a]\n: 1", ErrInvalidKey, 1, 3},
+		{"a: {<<: 1}", ErrInvalidMerge, 1, 9},
+		{"a: {<<: [1]}", ErrInvalidMerge, 1, 10},
+		{"a: &x {b: *x}", ErrRecursiveAlias, 1, 11},
+		{"a: 1\na: 2", ErrDuplicateKey, 2, 1},
+		{"a: {b: 1, b: 2}", ErrDuplicateKey, 1, 11},
+		{"a: [1", nil, 1, 0},
+		{"a: 'b", nil, 0, 0},
+		{"a: !!int b", nil, 1, 4},
+	}
+	for _, test := range tests {
+		v, err := (&Loader{}).LoadString(test.in)
+		if v != nil || err == nil {
+			t.Errorf("%q: LoadString() = %v, %v WANT error", test.in, v, err)
+			continue
+		}
+		parseError, ok := err.(*ParseError)
+		if !ok || parseError.Line != test.line || parseError.Column != test.column {
+			t.Errorf("%q: err = %#v WANT *ParseError at %v:%v", test.in, err, test.line, test.column)
+			continue
+		}
+		if test.err == nil {
+			if strings.Contains(parseError.Err.Error(), "line") {
+				t.Errorf("%q: err = %v WANT no position in Err", test.in, err)
+			}
+			continue
+		}
+		if parseError.Err != test.err {
+			t.Errorf("%q: err = %#v WANT %v at %v:%v", test.in, err, test.err, test.line, test.column)
+		}
+	}
+}
+
+func testLoadStringWithWantedValues(t *testing.T, l *Loader, in string, want *config.Values) {
+	v, err := l.LoadString(in)
+	if err != nil {
+		t.Error(err)
+	}
+	if !v.Equal(want) {
+		t.Fail()
+	}
+}
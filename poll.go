@@ -0,0 +1,171 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"time"
+)
+
+//DefaultPollInterval is the interval used by a Poller whose Interval is zero.
+const DefaultPollInterval = time.Second
+
+//Poller reloads a Config whenever a file loaded by any of its Loaders changes.
+//The files are those listed by Loaders added with Config.AddLoaders() that are
+//FileLoaders, such as those created by NewFileFuncLoader().
+//
+//Files are polled, and so no platform specific dependencies are needed.
+//A file is changed if it is created, removed, or its content changes. Content
+//is only hashed, with SHA-256, when a file's modification time or size changes,
+//and so a file that is merely touched is not changed.
+//
+//Reloads are done with Config.Reload(), which notifies the Config's watchers
+//(see Config.Watch()) of the resulting Changes. If the reload fails, e.g. because
+//a file no longer parses, then the Config keeps its previous values for that
+//file, and the file is reloaded once it changes again.
+type Poller struct {
+	//Interval is the time between polls. DefaultPollInterval is used if it is zero.
+	Interval time.Duration
+
+	//Debounce is how long files must stay unchanged after a change before the
+	//Config is reloaded, so that a file being written or several files being
+	//changed together result in a single reload.
+	//The zero value reloads on the first poll that finds a change.
+	Debounce time.Duration
+
+	//OnReload, if not nil, is called with the result of every reload.
+	OnReload func(changes []Change, err error)
+
+	config *Config
+
+	//files holds the state of each polled path as of the last poll.
+	files map[string]fileState
+
+	//pending indicates whether or not a change has been found that has not yet
+	//been reloaded, and changedAt is when the last change was found.
+	pending   bool
+	changedAt time.Time
+}
+
+//NewPoller creates a *Poller that reloads c.
+func NewPoller(c *Config) *Poller {
+	return &Poller{
+		config: c,
+	}
+}
+
+//Run polls until ctx is done and then returns ctx.Err().
+//The state of all files is recorded when Run starts, and so changes made before
+//then are not reloaded.
+//Run must not be called concurrently with itself.
+func (p *Poller) Run(ctx context.Context) error {
+	p.files, _ = p.scan(p.files)
+
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			p.poll(ctx, now)
+		}
+	}
+}
+
+//poll scans p's files and reloads p.config if a change found at or before now
+//has been debounced.
+func (p *Poller) poll(ctx context.Context, now time.Time) {
+	files, changed := p.scan(p.files)
+	p.files = files
+	if changed {
+		p.pending, p.changedAt = true, now
+	}
+	if !p.pending || now.Sub(p.changedAt) < p.Debounce {
+		return
+	}
+	p.pending = false
+
+	changes, err := p.config.ReloadContext(ctx)
+	if p.OnReload != nil {
+		p.OnReload(changes, err)
+	}
+}
+
+//scan returns the current state of all files of p.config's Loaders and whether
+//or not any of them changed since previous.
+func (p *Poller) scan(previous map[string]fileState) (map[string]fileState, bool) {
+	p.config.lock.Lock()
+	loaders := p.config.loaders
+	p.config.lock.Unlock()
+
+	files := map[string]fileState{}
+	changed := false
+	for _, loader := range loaders {
+		for _, path := range LoaderPaths(loader) {
+			if _, ok := files[path]; ok {
+				continue
+			}
+			before, ok := previous[path]
+			files[path] = statFile(path, before)
+			changed = changed || !ok || files[path].changed(before)
+		}
+	}
+	return files, changed || len(files) != len(previous)
+}
+
+//fileState is the state of a polled file.
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+//statFile returns the current state of the file at path, whose state was before.
+//The file is only hashed if its modification time or size differ from before.
+func statFile(path string, before fileState) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	state := fileState{
+		exists:  true,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+	if before.exists && before.modTime.Equal(state.modTime) && before.size == state.size {
+		state.hash = before.hash
+		return state
+	}
+	state.hash = hashFile(path)
+	return state
+}
+
+//hashFile returns the SHA-256 hash of the content of the file at path, or the
+//zero hash if it cannot be read.
+func hashFile(path string) (result [sha256.Size]byte) {
+	file, err := os.Open(path)
+	if err != nil {
+		return result
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return result
+	}
+	copy(result[:], hash.Sum(nil))
+	return result
+}
+
+//changed determines whether or not s differs from before in existence or content.
+func (s fileState) changed(before fileState) bool {
+	return s.exists != before.exists || s.hash != before.hash
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//contentLoader is a ReaderFuncLoader that stores the content it reads at the Key
+//[content], and fails for the content "invalid".
+func contentLoader(r io.Reader) (*Values, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if string(content) == "invalid" {
+		return nil, errors.New("invalid")
+	}
+	v := NewValues()
+	v.Put(NewKey("content"), string(content))
+	return v, nil
+}
+
+func newPollerTest(t *testing.T) (path string, c *Config, reloads chan error, cleanup func()) {
+	dir, err := ioutil.TempDir("", "gogolfing.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path = filepath.Join(dir, "config")
+	writeFile(t, path, "a")
+
+	c = New().AddLoaders(NewFileFuncLoader(contentLoader, path))
+	if _, err := c.LoadAll(); err != nil {
+		t.Fatal(err)
+	}
+	return path, c, make(chan error, 10), func() { os.RemoveAll(dir) }
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoller_Run(t *testing.T) {
+	path, c, reloads, cleanup := newPollerTest(t)
+	defer cleanup()
+
+	changes := make(chan Change, 10)
+	c.Watch("content", func(change Change) {
+		changes <- change
+	})
+
+	p := NewPoller(c)
+	p.Interval = 5 * time.Millisecond
+	p.OnReload = func(_ []Change, err error) {
+		reloads <- err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- p.Run(ctx)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	writeFile(t, path, "bb")
+	if err := waitReload(t, reloads); err != nil {
+		t.Errorf("reload error = %v", err)
+	}
+	if change := <-changes; change.New != "bb" {
+		t.Errorf("change = %v WANT bb", change)
+	}
+
+	writeFile(t, path, "invalid")
+	if err := waitReload(t, reloads); err == nil {
+		t.Error("reload error should not be nil")
+	}
+	if content := c.GetString("content"); content != "bb" {
+		t.Errorf("content = %v WANT bb", content)
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-reloads:
+		t.Errorf("touching should not reload, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() = %v WANT %v", err, context.Canceled)
+	}
+}
+
+func waitReload(t *testing.T, reloads chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-reloads:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload")
+		return nil
+	}
+}
+
+func TestPoller_poll_debounce(t *testing.T) {
+	path, c, reloads, cleanup := newPollerTest(t)
+	defer cleanup()
+
+	p := NewPoller(c)
+	p.Debounce = time.Second
+	p.OnReload = func(_ []Change, err error) {
+		reloads <- err
+	}
+	ctx := context.Background()
+	start := time.Now()
+	p.files, _ = p.scan(nil)
+
+	writeFile(t, path, "bb")
+	p.poll(ctx, start)
+	writeFile(t, path, "ccc")
+	p.poll(ctx, start.Add(500*time.Millisecond))
+	p.poll(ctx, start.Add(1400*time.Millisecond))
+	if len(reloads) != 0 || c.GetString("content") != "a" {
+		t.Fatal("should not reload before the debounce")
+	}
+
+	p.poll(ctx, start.Add(1500*time.Millisecond))
+	if len(reloads) != 1 || c.GetString("content") != "ccc" {
+		t.Fatalf("reloads = %v, content = %v WANT 1, ccc", len(reloads), c.GetString("content"))
+	}
+	p.poll(ctx, start.Add(3*time.Second))
+	if len(reloads) != 1 {
+		t.Error("should only reload once")
+	}
+}
+
+func TestPoller_poll_bestEffort(t *testing.T) {
+	path, c, reloads, cleanup := newPollerTest(t)
+	defer cleanup()
+	c.BestEffort = true
+
+	p := NewPoller(c)
+	p.OnReload = func(_ []Change, err error) {
+		reloads <- err
+	}
+	ctx := context.Background()
+	p.files, _ = p.scan(nil)
+
+	writeFile(t, path, "invalid")
+	p.poll(ctx, time.Now())
+	if err := <-reloads; err == nil || c.GetString("content") != "a" {
+		t.Errorf("reload error = %v, content = %v WANT an error and a", err, c.GetString("content"))
+	}
+}
+
+func TestPoller_scan(t *testing.T) {
+	path, c, _, cleanup := newPollerTest(t)
+	defer cleanup()
+
+	p := NewPoller(c)
+	files, changed := p.scan(nil)
+	if !changed || !files[path].exists {
+		t.Fatalf("scan() = %v, %v", files, changed)
+	}
+	if _, changed = p.scan(files); changed {
+		t.Error("scan() should not change")
+	}
+
+	before := files[path]
+	writeFile(t, path, "b")
+	if err := os.Chtimes(path, before.modTime, before.modTime); err != nil {
+		t.Fatal(err)
+	}
+	if _, changed = p.scan(files); changed {
+		t.Error("scan() should not hash when modification time and size are unchanged")
+	}
+
+	later := before.modTime.Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if files, changed = p.scan(files); !changed {
+		t.Error("scan() should find changed content")
+	}
+
+	os.Remove(path)
+	if files, changed = p.scan(files); !changed || files[path].exists {
+		t.Error("scan() should find removed file")
+	}
+
+	c.AddLoaders(WithPolicy(NewFileFuncLoader(contentLoader, path+"2"), LoadOptional))
+	if _, changed = p.scan(files); !changed {
+		t.Error("scan() should find new path")
+	}
+}
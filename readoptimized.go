@@ -0,0 +1,59 @@
+package config
+
+import "sync/atomic"
+
+//NewReadOptimizedValues creates an empty *Values that is read optimized.
+//
+//Every write to a read optimized Values publishes an immutable snapshot of it
+//(see Values.Snapshot()) through an atomic.Value. Get(), GetOk(), and Source()
+//read from the most recently published snapshot, and so they never wait on
+//writers and do not lock or allocate, unless GetOk() returns a subtree.
+//In exchange, every write allocates a new snapshot.
+//This suits Values that are read far more often than they are written, e.g.
+//configuration read on every request.
+//
+//Reads observe every write that completed before they started.
+func NewReadOptimizedValues() *Values {
+	v := NewValues()
+	v.published = &atomic.Value{}
+	v.publish()
+	return v
+}
+
+//NewReadOptimized is New() with a Values created by NewReadOptimizedValues().
+func NewReadOptimized() *Config {
+	c := New()
+	c.values = NewReadOptimizedValues()
+	return c
+}
+
+//IsReadOptimized determines whether or not v is read optimized.
+//See NewReadOptimizedValues().
+func (v *Values) IsReadOptimized() bool {
+	return v.published != nil
+}
+
+//unlock publishes v if it is read optimized and then unlocks v.lock for writing.
+//All writes to v must release v.lock with unlock.
+func (v *Values) unlock() {
+	if v.published != nil {
+		v.publish()
+	}
+	v.lock.Unlock()
+}
+
+//publish stores a snapshot of v in v.published.
+//v.lock must be held by the caller.
+func (v *Values) publish() {
+	v.published.Store(v.subValues(v.root, nil))
+}
+
+//loadPublished returns the most recently published snapshot of v, or nil if v
+//is not read optimized.
+//The result is never modified, and so it may be read without locking.
+func (v *Values) loadPublished() *Values {
+	if v.published == nil {
+		return nil
+	}
+	return v.published.Load().(*Values)
+}
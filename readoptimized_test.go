@@ -0,0 +1,181 @@
+package config
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestNewReadOptimizedValues(t *testing.T) {
+	v := NewReadOptimizedValues()
+	if !v.IsReadOptimized() || NewValues().IsReadOptimized() {
+		t.Fatal("only NewReadOptimizedValues() should be read optimized")
+	}
+
+	v.PutSource(NewKey("a", "b"), "b", "source")
+	if value, ok := v.GetOk(NewKey("a", "b")); value != "b" || !ok {
+		t.Errorf("GetOk() = %v, %v WANT b, true", value, ok)
+	}
+	if source, ok := v.Source(NewKey("a", "b")); source != "source" || !ok {
+		t.Errorf("Source() = %v, %v WANT source, true", source, ok)
+	}
+
+	v.MarkSensitive(NewKey("a", "c"))
+	v.Put(NewKey("a", "c"), "c")
+	sub := v.Get(NewKey("a")).(*Values)
+	if !sub.IsSensitive(NewKey("c")) || sub.Get(NewKey("c")) != "c" {
+		t.Errorf("sub = %v WANT sensitive c", sub)
+	}
+
+	v.Remove(NewKey("a", "b"))
+	if _, ok := v.GetOk(NewKey("a", "b")); ok {
+		t.Error("a.b should be removed")
+	}
+
+	clone := v.Clone()
+	if !clone.IsReadOptimized() {
+		t.Error("clone should be read optimized")
+	}
+	clone.Put(NewKey("a", "c"), "clone")
+	if clone.Get(NewKey("a", "c")) != "clone" || v.Get(NewKey("a", "c")) != "c" {
+		t.Error("clone and v should be independent")
+	}
+}
+
+func TestNewReadOptimized(t *testing.T) {
+	c := NewReadOptimized()
+	c.Put("a", 1)
+	c.SetLayer("defaults", DefaultsPriority, newLayerValues("b", 2))
+	c.Put("a", 3)
+
+	if a, b := c.GetInt64("a"), c.GetInt64("b"); a != 3 || b != 2 {
+		t.Errorf("a, b = %v, %v WANT 3, 2", a, b)
+	}
+	if !c.Values().IsReadOptimized() || !c.Clone().Values().IsReadOptimized() {
+		t.Error("c should be read optimized")
+	}
+}
+
+func TestNewReadOptimizedValues_concurrent(t *testing.T) {
+	v := NewReadOptimizedValues()
+	v.Put(NewKey("a"), 0)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 1000; i++ {
+			v.Put(NewKey("a"), i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		last := 0
+		for i := 0; i < 1000; i++ {
+			value := v.Get(NewKey("a")).(int)
+			if value < last {
+				t.Errorf("Get() = %v after %v", value, last)
+				return
+			}
+			last = value
+		}
+	}()
+	wg.Wait()
+}
+
+var benchmarkKey = NewKey("server", "http", "port")
+
+func newBenchmarkValues(v *Values) *Values {
+	for i := 0; i < 100; i++ {
+		v.Put(NewKey("server", "http", "header"+strconv.Itoa(i)), i)
+	}
+	v.Put(benchmarkKey, 8080)
+	return v
+}
+
+func benchmarkGet(b *testing.B, v *Values) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v.Get(benchmarkKey)
+	}
+}
+
+func benchmarkGetParallel(b *testing.B, v *Values, writing bool) {
+	done := make(chan struct{})
+	if writing {
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+					v.Put(NewKey("server", "http", "header0"), i)
+				}
+			}
+		}()
+	}
+	defer close(done)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			v.Get(benchmarkKey)
+		}
+	})
+}
+
+func BenchmarkValues_Get(b *testing.B) {
+	benchmarkGet(b, newBenchmarkValues(NewValues()))
+}
+
+func BenchmarkValues_Get_readOptimized(b *testing.B) {
+	benchmarkGet(b, newBenchmarkValues(NewReadOptimizedValues()))
+}
+
+func BenchmarkValues_Get_parallel(b *testing.B) {
+	benchmarkGetParallel(b, newBenchmarkValues(NewValues()), false)
+}
+
+func BenchmarkValues_Get_parallelReadOptimized(b *testing.B) {
+	benchmarkGetParallel(b, newBenchmarkValues(NewReadOptimizedValues()), false)
+}
+
+func BenchmarkValues_Get_parallelWriting(b *testing.B) {
+	benchmarkGetParallel(b, newBenchmarkValues(NewValues()), true)
+}
+
+func BenchmarkValues_Get_parallelWritingReadOptimized(b *testing.B) {
+	benchmarkGetParallel(b, newBenchmarkValues(NewReadOptimizedValues()), true)
+}
+
+func BenchmarkValues_Put(b *testing.B) {
+	v := newBenchmarkValues(NewValues())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v.Put(benchmarkKey, i)
+	}
+}
+
+func BenchmarkValues_Put_flat(b *testing.B) {
+	keys := make([]Key, 10000)
+	for i := range keys {
+		keys[i] = NewKey("key" + strconv.Itoa(i))
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v := NewValues()
+		for _, key := range keys {
+			v.Put(key, i)
+		}
+	}
+}
+
+func BenchmarkValues_Put_readOptimized(b *testing.B) {
+	v := newBenchmarkValues(NewReadOptimizedValues())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v.Put(benchmarkKey, i)
+	}
+}
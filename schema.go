@@ -0,0 +1,158 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+)
+
+//Field declares a single Key within a Schema.
+type Field struct {
+	//Key is the full Key that Field declares.
+	//Any value stored at a Key starting with Key is known to the Schema.
+	Key Key
+
+	//Type is the type that the value(s) at Key must be decodable into with
+	//Values.DecodeKey(), except that values are converted into bools and numbers
+	//as the Config Get*As methods do. A nil Type allows anything to be stored at Key.
+	//
+	//For example reflect.TypeOf(int64(0)) requires an integer type, or a string
+	//such as "80" from an environment variable, that fits in an int64, and
+	//reflect.TypeOf(map[string]string{}) requires a subtree of strings.
+	Type reflect.Type
+
+	//Default is the value put at Key by Schema.Load(). It is ignored if nil.
+	Default interface{}
+
+	//Required means a value must be stored at Key.
+	//Notice that defaults do not count towards Required unless they are loaded
+	//into the validated Values, e.g. with Schema.Load().
+	Required bool
+
+	//Description is a human readable help string for Key.
+	Description string
+}
+
+//Schema is a collection of Fields that can validate and provide defaults for
+//Values.
+//Schema is safe for use by multiple goroutines.
+//The zero value Schema declares no Fields and is ready to use.
+type Schema struct {
+	//AllowUnknown tells Validate() to permit values whose Keys are not declared
+	//by any Field.
+	//The zero value means undeclared Keys are violations, which catches typos
+	//such as "db.hots".
+	AllowUnknown bool
+
+	lock   sync.RWMutex
+	fields []Field
+}
+
+//NewSchema creates a *Schema that declares fields.
+func NewSchema(fields ...Field) *Schema {
+	s := &Schema{
+		fields: []Field{},
+	}
+	return s.Add(fields...)
+}
+
+//Add declares fields in s in addition to those already declared.
+//There is no check for duplicate Keys.
+func (s *Schema) Add(fields ...Field) *Schema {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, field := range fields {
+		field.Key = NewKey(field.Key...)
+		s.fields = append(s.fields, field)
+	}
+	return s
+}
+
+//Fields returns a copy of the Fields declared in s in the order they were added.
+func (s *Schema) Fields() []Field {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return append([]Field{}, s.fields...)
+}
+
+//Load is the config.Loader required method.
+//It returns a Values with every non nil Field.Default put at Field.Key.
+//Adding s as the first Loader of a Config therefore provides defaults that
+//later Loaders override.
+func (s *Schema) Load() (*Values, error) {
+	values := NewValues()
+	for _, field := range s.Fields() {
+		if field.Default != nil {
+			values.Put(field.Key, field.Default)
+		}
+	}
+	return values, nil
+}
+
+//Name returns "schema defaults".
+func (s *Schema) Name() string {
+	return "schema defaults"
+}
+
+//Validate checks v against every Field in s and returns all violations at once
+//in a *ValidationError, or nil if there are none.
+//
+//A missing Required Field is reported as a *KeyNotFoundError, a value that is
+//not decodable into its Field.Type as a *TypeError, and (unless AllowUnknown
+//is set) a value whose Key does not start with any Field.Key as an
+//*UnknownKeyError.
+func (s *Schema) Validate(v *Values) error {
+	fields := s.Fields()
+
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	errs := []error{}
+	for _, field := range fields {
+		if err := validateField(v.root, field); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !s.AllowUnknown {
+		v.root.eachSortedKeyValue(nil, func(key Key, _ interface{}) {
+			if !isDeclared(fields, key) {
+				errs = append(errs, &UnknownKeyError{Key: key})
+			}
+		})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: errs}
+}
+
+func validateField(root *node, field Field) error {
+	found := root.descendent(field.Key)
+	if found == nil || found.isEmpty() {
+		if field.Required {
+			return &KeyNotFoundError{Key: field.Key}
+		}
+		return nil
+	}
+	if field.Type == nil {
+		return nil
+	}
+	return decoder{coerce: true}.decodeNode(NewKey(field.Key...), found, reflect.New(field.Type).Elem())
+}
+
+//isDeclared determines whether or not key starts with any Field.Key in fields.
+func isDeclared(fields []Field, key Key) bool {
+	for _, field := range fields {
+		if key.StartsWith(field.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+//Validate is sugar for s.Validate(c.Values()).
+func (c *Config) Validate(s *Schema) error {
+	return s.Validate(c.values)
+}
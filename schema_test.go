@@ -0,0 +1,173 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestSchema() *Schema {
+	return NewSchema(
+		Field{
+			Key:         NewKey("db", "host"),
+			Type:        reflect.TypeOf(""),
+			Required:    true,
+			Description: "database host",
+		},
+		Field{
+			Key:         NewKey("db", "port"),
+			Type:        reflect.TypeOf(uint16(0)),
+			Default:     5432,
+			Description: "database port",
+		},
+		Field{
+			Key:  NewKey("labels"),
+			Type: reflect.TypeOf(map[string]string{}),
+		},
+		Field{
+			Key: NewKey("extra"),
+		},
+	)
+}
+
+func TestNewSchema(t *testing.T) {
+	s := NewSchema()
+	if s.fields == nil || s.AllowUnknown {
+		t.Fail()
+	}
+}
+
+func TestSchema_zero(t *testing.T) {
+	s := &Schema{}
+	v := NewValues()
+	v.Put(NewKey("a"), "a")
+
+	if err := s.Validate(v); err == nil {
+		t.Error("s.Validate() = nil WANT *ValidationError")
+	}
+	s.Add(Field{Key: NewKey("a")})
+	if err := s.Validate(v); err != nil {
+		t.Error(err)
+	}
+	if fields := s.Fields(); len(fields) != 1 {
+		t.Errorf("s.Fields() = %v", fields)
+	}
+}
+
+func TestSchema_Add(t *testing.T) {
+	s := NewSchema(Field{Key: NewKey("a")})
+	result := s.Add(Field{Key: NewKey("b")})
+
+	want := []Field{{Key: NewKey("a")}, {Key: NewKey("b")}}
+	if result != s || !reflect.DeepEqual(s.Fields(), want) {
+		t.Errorf("s.Fields() = %v WANT %v", s.Fields(), want)
+	}
+}
+
+func TestSchema_Load(t *testing.T) {
+	v, err := newTestSchema().Load()
+
+	want := NewValues()
+	want.Put(NewKey("db", "port"), 5432)
+
+	if err != nil || !v.Equal(want) {
+		t.Errorf("s.Load() = %v, %v", v, err)
+	}
+}
+
+func TestSchema_Validate_valid(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("db", "host"), "localhost")
+	v.Put(NewKey("db", "port"), 5432)
+	v.Put(NewKey("labels", "a"), "a")
+	v.Put(NewKey("extra", "anything", "at", "all"), []int{1})
+
+	if err := newTestSchema().Validate(v); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSchema_Validate_violations(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("db", "hots"), "localhost")
+	v.Put(NewKey("db", "port"), 70000)
+	v.Put(NewKey("labels", "a"), 1)
+
+	err := newTestSchema().Validate(v)
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("s.Validate() = %v WANT *ValidationError", err)
+	}
+
+	want := []error{
+		&KeyNotFoundError{Key: NewKey("db", "host")},
+		&TypeError{Key: NewKey("db", "port"), Want: reflect.TypeOf(uint16(0)), Got: reflect.TypeOf(0)},
+		&TypeError{Key: NewKey("labels", "a"), Want: reflect.TypeOf(""), Got: reflect.TypeOf(0)},
+		&UnknownKeyError{Key: NewKey("db", "hots")},
+	}
+	if !reflect.DeepEqual(validationErr.Errors, want) {
+		t.Errorf("validationErr.Errors = %v WANT %v", validationErr.Errors, want)
+	}
+}
+
+func TestSchema_Validate_coerced(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("db", "host"), "localhost")
+	v.Put(NewKey("db", "port"), " 5432 ")
+	v.Put(NewKey("flag"), "true")
+	v.Put(NewKey("ratio"), "0.5")
+	v.Put(NewKey("negative"), "-1")
+
+	s := NewSchema(
+		Field{Key: NewKey("db", "host"), Type: reflect.TypeOf("")},
+		Field{Key: NewKey("db", "port"), Type: reflect.TypeOf(uint16(0))},
+		Field{Key: NewKey("flag"), Type: reflect.TypeOf(false)},
+		Field{Key: NewKey("ratio"), Type: reflect.TypeOf(float32(0))},
+		Field{Key: NewKey("negative"), Type: reflect.TypeOf(uint(0))},
+	)
+	err := s.Validate(v)
+	validationErr, ok := err.(*ValidationError)
+	if !ok || len(validationErr.Errors) != 1 {
+		t.Fatalf("s.Validate() = %v WANT a single violation", err)
+	}
+	typeErr, ok := validationErr.Errors[0].(*TypeError)
+	if !ok || !typeErr.Key.Equal(NewKey("negative")) || typeErr.Want != reflect.TypeOf(uint(0)) || typeErr.Err == nil {
+		t.Errorf("validationErr.Errors[0] = %#v", validationErr.Errors[0])
+	}
+
+	v.Put(NewKey("db", "port"), "70000")
+	v.Put(NewKey("negative"), "1")
+	if err := s.Validate(v); err == nil {
+		t.Error("s.Validate() = nil WANT overflow violation")
+	}
+}
+
+func TestSchema_Validate_allowUnknown(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("db", "host"), "localhost")
+	v.Put(NewKey("unknown"), true)
+
+	s := newTestSchema()
+	s.AllowUnknown = true
+	if err := s.Validate(v); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	s := newTestSchema()
+
+	c := New()
+	c.AddLoaders(s)
+	c.LoadAll()
+	c.Put("db.host", "localhost")
+
+	if err := c.Validate(s); err != nil {
+		t.Error(err)
+	}
+	if c.GetInt64("db.port") != 5432 {
+		t.Errorf("default db.port = %v WANT 5432", c.Get("db.port"))
+	}
+	if source, _ := c.Source("db.port"); source != "schema defaults" {
+		t.Errorf("c.Source(db.port) = %v", source)
+	}
+}
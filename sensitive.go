@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+	"path"
+)
+
+//Redacted replaces sensitive values in the output of Values.String(),
+//Values.Redacted(), and Change.String().
+const Redacted = "[REDACTED]"
+
+//AnyKeyParts is the pattern Key part that matches zero or more Key parts.
+//All other pattern Key parts match exactly one Key part with path.Match().
+const AnyKeyParts = "**"
+
+//MarkSensitive marks all values whose Keys match any of patterns as sensitive.
+//
+//Each Key part of a pattern matches a single Key part via path.Match(),
+//e.g. "*" matches any Key part, except for AnyKeyParts which matches any number
+//of Key parts. A value is sensitive if its Key, or any prefix of its Key,
+//matches a pattern.
+//With a PeriodSeparatorKeyParser, "*.password" matches the Keys [db password]
+//and [cache password], and "secrets" and "secrets.**" both match every Key
+//that starts with [secrets].
+//
+//Sensitive values are replaced by Redacted in v.String(), v.Redacted(), and the
+//String() of Changes reported by v, but are returned unaltered by v's getters.
+//The patterns are kept by v.Clone(), and the subtree Values returned by
+//v.Get() keep the patterns that apply within them.
+func (v *Values) MarkSensitive(patterns ...Key) {
+	v.lock.Lock()
+	defer v.unlock()
+
+	for _, pattern := range patterns {
+		v.sensitive = append(v.sensitive, NewKey(pattern...))
+	}
+}
+
+//IsSensitive determines whether or not the value at key is sensitive.
+//See v.MarkSensitive().
+func (v *Values) IsSensitive(key Key) bool {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	return v.sensitive.matches(key)
+}
+
+//Redacted returns a copy of v with every sensitive value replaced by Redacted.
+//The result is meant for logging and debugging output, e.g. via its EachKeyValue().
+func (v *Values) Redacted() *Values {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	return v.subValues(v.root.redacted(nil, v.sensitive), nil)
+}
+
+//String returns a fmt formatted version of all associations in v, with nested
+//maps for subtrees and sensitive values replaced by Redacted.
+func (v *Values) String() string {
+	return fmt.Sprint(v.Redacted().root.interfaceValue())
+}
+
+//sensitivity holds sensitive Key patterns.
+type sensitivity []Key
+
+//matches determines whether or not key, or any prefix of key, matches a pattern in s.
+func (s sensitivity) matches(key Key) bool {
+	for _, pattern := range s {
+		states := []Key{pattern}
+		if anyComplete(states) {
+			return true
+		}
+		for _, keyPart := range key {
+			states = advancePatterns(states, keyPart)
+			if len(states) == 0 {
+				break
+			}
+			if anyComplete(states) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//rebase returns the patterns that match Keys relative to prefix, such that
+//s.rebase(prefix).matches(key) == s.matches(prefix.Append(key)).
+func (s sensitivity) rebase(prefix Key) sensitivity {
+	if len(s) == 0 {
+		return nil
+	}
+	states := make([]Key, len(s))
+	copy(states, s)
+	for _, keyPart := range prefix {
+		if anyComplete(states) {
+			return sensitivity{Key{}}
+		}
+		states = advancePatterns(states, keyPart)
+	}
+	return sensitivity(states)
+}
+
+//markChanges sets the Sensitive field of each Change whose Key matches s.
+func (s sensitivity) markChanges(changes []Change) {
+	for i := range changes {
+		if s.matches(changes[i].Key) {
+			changes[i].Sensitive = true
+		}
+	}
+}
+
+//anyComplete determines whether or not any pattern in patterns matches an empty Key.
+func anyComplete(patterns []Key) bool {
+	for _, pattern := range patterns {
+		if patternComplete(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+//patternComplete determines whether or not pattern matches an empty Key.
+func patternComplete(pattern Key) bool {
+	for _, patternPart := range pattern {
+		if patternPart != AnyKeyParts {
+			return false
+		}
+	}
+	return true
+}
+
+//advancePatterns returns the remaining patterns after each pattern in patterns
+//consumes keyPart. Patterns that cannot consume keyPart are dropped.
+func advancePatterns(patterns []Key, keyPart string) []Key {
+	result := []Key{}
+	seen := map[string]bool{}
+	add := func(pattern Key) {
+		id := keyID(pattern)
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, pattern)
+		}
+	}
+	var advance func(pattern Key)
+	advance = func(pattern Key) {
+		if pattern.IsEmpty() {
+			return
+		}
+		if pattern[0] == AnyKeyParts {
+			add(pattern)
+			advance(pattern[1:])
+			return
+		}
+		if matchKeyPart(pattern[0], keyPart) {
+			add(pattern[1:])
+		}
+	}
+	for _, pattern := range patterns {
+		advance(pattern)
+	}
+	return result
+}
+
+//matchKeyPart determines whether or not keyPart matches the path.Match() pattern
+//patternPart. Malformed patterns only match themselves.
+func matchKeyPart(patternPart, keyPart string) bool {
+	matched, err := path.Match(patternPart, keyPart)
+	if err != nil {
+		return patternPart == keyPart
+	}
+	return matched
+}
+
+//redacted returns a copy of n, stored at key, with all values that are sensitive
+//according to s replaced by Redacted.
+func (n *node) redacted(key Key, s sensitivity) *node {
+	if n.isSet() {
+		result := n.clone()
+		if s.matches(key) {
+			result.value = Redacted
+		}
+		return result
+	}
+	result := &node{
+		children:   make(map[string]*node, len(n.children)),
+		source:     n.source,
+		generation: n.generation,
+	}
+	for keyPart, child := range n.children {
+		result.children[keyPart] = child.redacted(key.AppendStrings(keyPart), s)
+	}
+	return result
+}
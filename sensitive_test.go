@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestValues_IsSensitive(t *testing.T) {
+	v := NewValues()
+	v.MarkSensitive(
+		NewKey("*", "password"),
+		NewKey("secrets", AnyKeyParts),
+		NewKey(AnyKeyParts, "*_token"),
+		NewKey("exact"),
+	)
+
+	tests := []struct {
+		key       Key
+		sensitive bool
+	}{
+		{NewKey("db", "password"), true},
+		{NewKey("db", "password", "nested"), true},
+		{NewKey("password"), false},
+		{NewKey("a", "b", "password"), false},
+		{NewKey("db", "host"), false},
+		{NewKey("secrets"), true},
+		{NewKey("secrets", "a", "b"), true},
+		{NewKey("api_token"), true},
+		{NewKey("a", "b", "c", "api_token"), true},
+		{NewKey("a", "api_token", "c"), true},
+		{NewKey("a", "api_tokens"), false},
+		{NewKey("exact"), true},
+		{NewKey("exact", "child"), true},
+		{NewKey("notexact"), false},
+		{NewKey(), false},
+	}
+
+	for _, test := range tests {
+		if sensitive := v.IsSensitive(test.key); sensitive != test.sensitive {
+			t.Errorf("v.IsSensitive(%v) = %v WANT %v", test.key, sensitive, test.sensitive)
+		}
+	}
+}
+
+func TestValues_IsSensitive_none(t *testing.T) {
+	if NewValues().IsSensitive(NewKey("a")) {
+		t.Error("values without patterns should not be sensitive")
+	}
+}
+
+func TestSensitivity_rebase(t *testing.T) {
+	s := sensitivity{
+		NewKey("*", "password"),
+		NewKey("secrets", AnyKeyParts),
+		NewKey(AnyKeyParts, "token"),
+	}
+	keys := []Key{
+		NewKey(),
+		NewKey("password"),
+		NewKey("a", "password"),
+		NewKey("a", "token"),
+		NewKey("token"),
+		NewKey("other"),
+	}
+	prefixes := []Key{
+		nil,
+		NewKey("db"),
+		NewKey("secrets"),
+		NewKey("secrets", "inner"),
+		NewKey("a", "b"),
+	}
+
+	for _, prefix := range prefixes {
+		rebased := s.rebase(prefix)
+		for _, key := range keys {
+			want := s.matches(prefix.Append(key))
+			if got := rebased.matches(key); got != want {
+				t.Errorf("rebase(%v).matches(%v) = %v WANT %v", prefix, key, got, want)
+			}
+		}
+	}
+}
+
+func TestValues_Redacted(t *testing.T) {
+	v := NewValues()
+	v.PutSource(NewKey("db", "password"), "hunter2", "env DB_PASSWORD")
+	v.Put(NewKey("db", "host"), "localhost")
+	v.MarkSensitive(NewKey("db", "password"))
+
+	redacted := v.Redacted()
+
+	want := NewValues()
+	want.PutSource(NewKey("db", "password"), Redacted, "env DB_PASSWORD")
+	want.Put(NewKey("db", "host"), "localhost")
+	if !redacted.Equal(want) {
+		t.Errorf("v.Redacted() = %v WANT %v", redacted.root.interfaceValue(), want.root.interfaceValue())
+	}
+	if source, _ := redacted.Source(NewKey("db", "password")); source != "env DB_PASSWORD" {
+		t.Errorf("redacted source = %q", source)
+	}
+	if password := v.Get(NewKey("db", "password")); password != "hunter2" {
+		t.Errorf("v.Get() = %v WANT hunter2", password)
+	}
+}
+
+func TestValues_String(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("a"), 1)
+	v.Put(NewKey("b", "c"), "secret")
+	v.MarkSensitive(NewKey("b"))
+
+	want := "map[a:1 b:map[c:[REDACTED]]]"
+	if s := v.String(); s != want {
+		t.Errorf("v.String() = %q WANT %q", s, want)
+	}
+	if s := fmt.Sprint(v); s != want {
+		t.Errorf("fmt.Sprint(v) = %q WANT %q", s, want)
+	}
+}
+
+func TestValues_sensitiveSubtrees(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("db", "password"), "hunter2")
+	v.Put(NewKey("db", "host"), "localhost")
+	v.MarkSensitive(NewKey("*", "password"))
+
+	db := v.Get(NewKey("db")).(*Values)
+	if !db.IsSensitive(NewKey("password")) || db.IsSensitive(NewKey("host")) {
+		t.Error("subtree Values should keep the applicable patterns")
+	}
+	if !v.Clone().IsSensitive(NewKey("db", "password")) {
+		t.Error("v.Clone() should keep the patterns")
+	}
+}
+
+func TestValues_Diff_sensitive(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("password"), "old")
+	v.MarkSensitive(NewKey("password"))
+
+	other := NewValues()
+	other.Put(NewKey("password"), "new")
+
+	want := []Change{
+		{Kind: ChangeModified, Key: NewKey("password"), Old: "old", New: "new", Sensitive: true},
+	}
+	changes := v.Diff(other)
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("v.Diff() = %#v WANT %#v", changes, want)
+	}
+	if s := changes[0].String(); s != "modified [password]: [REDACTED] -> [REDACTED]" {
+		t.Errorf("change.String() = %q", s)
+	}
+	if changes := other.Diff(v); !changes[0].Sensitive {
+		t.Error("other.Diff(v) should be sensitive")
+	}
+}
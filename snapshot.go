@@ -0,0 +1,157 @@
+package config
+
+//Snapshot returns a Values with all associations in v in O(1) time.
+//The result and v share their underlying tree until either is modified, and
+//modifications to either DO NOT AFFECT the other.
+//This allows readers to hold a consistent view of v while writers keep
+//modifying v.
+//As with v.Clone(), the individual values are shallow copied, and the result
+//keeps v's sensitive patterns and EqualFunc.
+func (v *Values) Snapshot() *Values {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	return v.subValues(v.root, nil)
+}
+
+//snapshotValue returns a snapshot of value if it is a *Values, and value otherwise.
+//
+//A *Values given to a method of another Values is snapshotted before that
+//Values is locked, so that the method never holds both locks at once. This
+//allows a Values to be given to its own methods and to methods of a Values
+//that is concurrently given to its methods, without deadlock.
+func snapshotValue(value interface{}) interface{} {
+	if values, ok := value.(*Values); ok {
+		return values.Snapshot()
+	}
+	return value
+}
+
+//mutation holds the state of a single modification of a Values tree.
+//
+//Values trees are persistent: nodes that are shared with another Values, such
+//as a snapshot, are never modified. Instead, such a node is copied, along with
+//all of its ancestors, before it is modified, and the copies replace the
+//originals in the tree.
+//Each node records the generation of the Values that created or copied it, and
+//a Values modifies the nodes of its current generation in place. A Values starts
+//a new generation whenever it shares its tree (see v.share()), and so only the
+//first modification of a node after each snapshot copies it, while a run of
+//modifications, such as those of a loader, modifies the same nodes in place.
+//Trees may therefore be shared by any number of Values.
+type mutation struct {
+	//equal is the EqualFunc of the Values being modified.
+	equal EqualFunc
+
+	//generation is the current generation of the Values being modified.
+	generation *generation
+}
+
+//generation identifies the nodes that a Values may modify in place.
+//Generations are compared by identity, and so generation is not empty, as
+//distinct pointers to empty values may be equal.
+type generation struct {
+	_ byte
+}
+
+//noGeneration is the generation of nodes that are not created by a mutation,
+//such as the root of NewValues(). No Values modifies them in place.
+var noGeneration = &generation{}
+
+//mutation returns a new mutation with which to modify v's tree.
+//v.lock must be held by the caller for writing.
+func (v *Values) mutation() mutation {
+	current, ok := v.generation.Load().(*generation)
+	if !ok {
+		current = &generation{}
+		v.generation.Store(current)
+	}
+	return mutation{
+		equal:      v.equalFunc(),
+		generation: current,
+	}
+}
+
+//share starts a new generation for v, so that the nodes of v's tree are no
+//longer modified in place and may be shared with another Values.
+//v.lock must be held by the caller.
+func (v *Values) share() {
+	v.generation.Store(&generation{})
+}
+
+//writable returns n if it was created or copied by m's generation, and a copy of
+//n otherwise.
+func (m mutation) writable(n *node) *node {
+	if n.generation == m.generation {
+		return n
+	}
+	result := n.copy()
+	result.generation = m.generation
+	return result
+}
+
+//newNode returns a new empty node that is writable by m.
+func (m mutation) newNode() *node {
+	result := newNode()
+	result.generation = m.generation
+	return result
+}
+
+//writableRoot replaces v.root with a copy, if needed, so that it is writable
+//by m, and returns it.
+//v.lock must be held by the caller for writing.
+func (m mutation) writableRoot(v *Values) *node {
+	v.root = m.writable(v.root)
+	return v.root
+}
+
+//writableDescendent returns the existing node stored at key in v after
+//copying it, and all of its ancestors, as needed so that it is writable by m.
+//v.lock must be held by the caller for writing.
+func (m mutation) writableDescendent(v *Values, key Key) *node {
+	n := m.writableRoot(v)
+	for _, keyPart := range key {
+		child := m.writable(n.children[keyPart])
+		n.children[keyPart] = child
+		n = child
+	}
+	return n
+}
+
+//copy returns a shallow copy of n with the same child nodes.
+func (n *node) copy() *node {
+	result := &node{
+		value:      n.value,
+		source:     n.source,
+		list:       n.list,
+		generation: n.generation,
+	}
+	if n.children != nil {
+		result.children = make(map[string]*node, len(n.children))
+		for keyPart, child := range n.children {
+			result.children[keyPart] = child
+		}
+	}
+	return result
+}
+
+//writableChild returns the child of n at keyPart such that it is writable by m,
+//creating it, or turning n into a subtree, as needed.
+//n must be writable by m.
+//changed indicates whether or not n's set of associations changed.
+func (n *node) writableChild(keyPart string, m mutation) (child *node, changed bool) {
+	if n.isSet() {
+		n.value, changed = nil, true
+		n.children = map[string]*node{}
+		n.source = ""
+	}
+	child, ok := n.children[keyPart]
+	if ok {
+		child = m.writable(child)
+	} else {
+		child = m.newNode()
+		changed = true
+	}
+	n.children[keyPart] = child
+	return child, changed
+}
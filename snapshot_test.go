@@ -0,0 +1,150 @@
+package config
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestValues_Clone_independent(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("a", "b"), "b")
+
+	clone := v.Clone()
+	clone.Put(NewKey("a", "b"), "changed")
+	clone.Put(NewKey("a", "c"), "c")
+	v.Put(NewKey("d"), "d")
+
+	if b := v.Get(NewKey("a", "b")); b != "b" {
+		t.Errorf("v a.b = %v WANT b", b)
+	}
+	if _, ok := v.GetOk(NewKey("a", "c")); ok {
+		t.Error("v should not have a.c")
+	}
+	if _, ok := clone.GetOk(NewKey("d")); ok {
+		t.Error("clone should not have d")
+	}
+}
+
+func TestValues_Snapshot(t *testing.T) {
+	v := NewValues()
+	v.PutSource(NewKey("a", "b"), "b", "source")
+	v.Put(NewKey("a", "c"), "c")
+	v.MarkSensitive(NewKey("a", "b"))
+
+	snapshot := v.Snapshot()
+	if !snapshot.Equal(v) || !snapshot.IsSensitive(NewKey("a", "b")) {
+		t.Fatal("snapshot should equal v")
+	}
+
+	v.Put(NewKey("a", "b"), "changed")
+	v.Remove(NewKey("a", "c"))
+	v.Put(NewKey("a"), "a")
+
+	want := NewValues()
+	want.PutSource(NewKey("a", "b"), "b", "source")
+	want.Put(NewKey("a", "c"), "c")
+	if !snapshot.Equal(want) {
+		t.Errorf("snapshot = %v WANT %v", snapshot, want)
+	}
+	if source, _ := snapshot.Source(NewKey("a", "b")); source != "source" {
+		t.Errorf("snapshot source = %q WANT source", source)
+	}
+
+	snapshot.Put(NewKey("a", "b"), "snapshot")
+	if a := v.Get(NewKey("a")); a != "a" {
+		t.Errorf("v a = %v WANT a", a)
+	}
+}
+
+func TestValues_GetOk_subtreeIndependent(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("a", "b", "c"), "c")
+
+	sub := v.Get(NewKey("a")).(*Values)
+	sub.Put(NewKey("b", "c"), "sub")
+	v.Put(NewKey("a", "b", "d"), "d")
+
+	if c := v.Get(NewKey("a", "b", "c")); c != "c" {
+		t.Errorf("v a.b.c = %v WANT c", c)
+	}
+	if _, ok := sub.GetOk(NewKey("b", "d")); ok {
+		t.Error("sub should not have b.d")
+	}
+
+	removed, _ := v.Remove(NewKey("a"))
+	removed.(*Values).Put(NewKey("b", "c"), "removed")
+	if c := sub.Get(NewKey("b", "c")); c != "sub" {
+		t.Errorf("sub b.c = %v WANT sub", c)
+	}
+}
+
+func TestValues_Snapshot_repeatedWrites(t *testing.T) {
+	v := NewValues()
+	for i := 0; i < 10; i++ {
+		v.Put(NewKey("a", strconv.Itoa(i)), i)
+	}
+
+	snapshot := v.Snapshot()
+	sub := v.Get(NewKey("a")).(*Values)
+	for round := 1; round <= 3; round++ {
+		for i := 0; i < 10; i++ {
+			v.Put(NewKey("a", strconv.Itoa(i)), -round)
+		}
+		v.Put(NewKey("b", strconv.Itoa(round)), round)
+	}
+
+	for i := 0; i < 10; i++ {
+		if value := snapshot.Get(NewKey("a", strconv.Itoa(i))); value != i {
+			t.Errorf("snapshot a.%v = %v WANT %v", i, value, i)
+		}
+		if value := sub.Get(NewKey(strconv.Itoa(i))); value != i {
+			t.Errorf("sub %v = %v WANT %v", i, value, i)
+		}
+		if value := v.Get(NewKey("a", strconv.Itoa(i))); value != -3 {
+			t.Errorf("v a.%v = %v WANT -3", i, value)
+		}
+	}
+	if _, ok := snapshot.GetOk(NewKey("b")); ok {
+		t.Error("snapshot should not have b")
+	}
+
+	again := v.Snapshot()
+	v.Put(NewKey("b", "1"), "changed")
+	if value := again.Get(NewKey("b", "1")); value != 1 {
+		t.Errorf("again b.1 = %v WANT 1", value)
+	}
+}
+
+func TestValues_Snapshot_concurrent(t *testing.T) {
+	v := NewValues()
+	for i := 0; i < 10; i++ {
+		v.Put(NewKey("a", strconv.Itoa(i)), 0)
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for round := 1; round <= 100; round++ {
+			for i := 0; i < 10; i++ {
+				v.Put(NewKey("a", strconv.Itoa(i)), round)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for round := 0; round < 100; round++ {
+			snapshot := v.Snapshot()
+			first := snapshot.Get(NewKey("a", "0")).(int)
+			for i := 1; i < 10; i++ {
+				value := snapshot.Get(NewKey("a", strconv.Itoa(i))).(int)
+				if value > first {
+					t.Errorf("snapshot a.%v = %v is newer than a.0 = %v", i, value, first)
+					return
+				}
+			}
+		}
+	}()
+	wg.Wait()
+}
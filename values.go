@@ -1,6 +1,11 @@
 package config
 
-import "sync"
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
 
 //Values provides storage of arbitrary interface{} values referenced by type Key.
 //The zero value for Values is not in a valid state, thus Values should be
@@ -9,35 +14,90 @@ import "sync"
 type Values struct {
 	lock *sync.RWMutex
 	root *node
+
+	//sensitive holds the patterns of sensitive Keys. See MarkSensitive().
+	sensitive sensitivity
+
+	//equal compares values. nil means DefaultEqual. See SetEqualFunc().
+	equal EqualFunc
+
+	//published holds the read only snapshot of v that reads use instead of lock
+	//when v is read optimized, and is nil otherwise. See NewReadOptimizedValues().
+	published *atomic.Value
+
+	//generation holds the *generation of the nodes of v's tree that v modifies
+	//in place. See mutation.
+	generation atomic.Value
 }
 
 //NewValues creates an empty *Values.
 func NewValues() *Values {
-	return &Values{
-		lock: &sync.RWMutex{},
-		root: newNode(),
-	}
+	return newValues(newNode())
 }
 
 func newValues(root *node) *Values {
-	return &Values{
+	result := &Values{
 		lock: &sync.RWMutex{},
 		root: root,
 	}
+	result.generation.Store(&generation{})
+	return result
+}
+
+//subValues returns a new Values with root, which is stored at key in v, that
+//keeps v's sensitive patterns that apply at key and v's EqualFunc.
+//root is shared with the result, and so v.share() is called.
+//v.lock must be held by the caller.
+func (v *Values) subValues(root *node, key Key) *Values {
+	v.share()
+	result := newValues(root)
+	result.sensitive = v.sensitive.rebase(key)
+	result.equal = v.equal
+	return result
 }
 
 //Merge merges all associations in other into v starting at key.
 //To merge other in v at root, use an empty Key for key.
+//Lists in other that were put with PutList() replace whatever is stored at their
+//Keys in v as a whole, and so merging a shorter list does not keep the trailing
+//elements of a longer one. All other subtrees are merged key by key, even those
+//whose Key parts happen to be indices, and so merging [servers 0 port] only
+//changes the port of the first element of a list stored at [servers].
+//Merge uses a snapshot of other (see other.Snapshot()) taken before v is locked,
+//and so other may be v itself or be concurrently merged into v.
 func (v *Values) Merge(key Key, other *Values) bool {
+	other = other.Snapshot()
+
 	v.lock.Lock()
-	defer v.lock.Unlock()
+	defer v.unlock()
 
-	changed := false
-	other.EachKeyValue(func(otherKey Key, value interface{}) {
-		actualKey := key.Append(otherKey)
-		changed = v.put(actualKey, value) || changed
-	})
-	return changed
+	return v.merge(key, other)
+}
+
+//MergeSource is v.Merge() where the values in other that have no recorded
+//source (see v.Source()) are put into v with source, as Config.MergeLoaders()
+//does with the name of each Loader.
+func (v *Values) MergeSource(key Key, other *Values, source string) bool {
+	other = other.Snapshot()
+
+	v.lock.Lock()
+	defer v.unlock()
+
+	return v.mergeSource(key, other, source)
+}
+
+func (v *Values) merge(key Key, other *Values) bool {
+	return v.mergeSource(key, other, "")
+}
+
+//mergeSource is v.merge(key, other) where values in other without a source
+//are put into v with source.
+func (v *Values) mergeSource(key Key, other *Values, source string) bool {
+	other.lock.RLock()
+	defer other.lock.RUnlock()
+
+	m := v.mutation()
+	return m.writableRoot(v).merge(key, other.root, source, m)
 }
 
 //EachKeyValue calls visitor with each set Key value association in v.
@@ -48,30 +108,128 @@ func (v *Values) EachKeyValue(visitor func(key Key, value interface{})) {
 	v.root.eachKeyValue(nil, visitor)
 }
 
+//EachKeyValueSource is v.EachKeyValue() that also visits each value's source,
+//which is empty if none is recorded. See v.Source().
+func (v *Values) EachKeyValueSource(visitor func(key Key, value interface{}, source string)) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	v.root.eachKeyValueSource(nil, visitor)
+}
+
 //Equal determines whether or not v and other contain the exact same set of
 //Keys and associated values.
-//Comparison on a value by value basis is done with the == operator.
+//Comparison on a value by value basis is done with v's EqualFunc.
+//As with v.Merge(), other may be v itself or be concurrently compared with v.
 func (v *Values) Equal(other *Values) bool {
+	other = other.Snapshot()
+
 	v.lock.RLock()
 	defer v.lock.RUnlock()
-	other.lock.RLock()
-	defer other.lock.RUnlock()
 
-	return v.root.equal(other.root)
+	return v.root.equal(other.root, v.equalFunc())
+}
+
+//Diff returns the Changes needed to turn the associations in v into those in
+//other, ordered by Key.
+//A value that is replaced by a subtree of values is reported as the removal of
+//that value followed by the addition of every value in the subtree, and vice versa.
+//Comparison on a value by value basis is done with v's EqualFunc.
+//As with v.Merge(), other may be concurrently compared with v.
+func (v *Values) Diff(other *Values) []Change {
+	if v == other {
+		return nil
+	}
+
+	other = other.Snapshot()
+
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	changes := collectChanges(nil, v.root, other.root, v.equalFunc())
+	for i := range changes {
+		changes[i].Sensitive = v.sensitive.matches(changes[i].Key) || other.sensitive.matches(changes[i].Key)
+	}
+	return changes
 }
 
 //Put adds the key, value association to v.
 //changed indicates whether or not this operation changes the set of associations
 //in any way.
+//If value is a *Values, then a snapshot of it is put, and so value may be v itself.
 func (v *Values) Put(key Key, value interface{}) (changed bool) {
+	value = snapshotValue(value)
+
 	v.lock.Lock()
-	defer v.lock.Unlock()
+	defer v.unlock()
 
 	return v.put(key, value)
 }
 
 func (v *Values) put(key Key, value interface{}) bool {
-	return v.root.put(key, value)
+	m := v.mutation()
+	return m.writableRoot(v).put(key, value, "", m)
+}
+
+//PutSource is v.Put(key, value) that additionally records source as the
+//description of where value came from.
+//If value is a *Values, then source is only recorded for the values in it that
+//do not already have a source.
+//Sources are replaced even if the value at key does not change.
+//See v.Source().
+func (v *Values) PutSource(key Key, value interface{}, source string) (changed bool) {
+	value = snapshotValue(value)
+
+	v.lock.Lock()
+	defer v.unlock()
+
+	m := v.mutation()
+	return m.writableRoot(v).put(key, value, source, m)
+}
+
+//PutList stores a list of length elements at key.
+//Elements already stored at the indices "0" through "length-1" are kept, empty
+//elements are stored at the other indices, and everything else stored at key is
+//removed.
+//Values are then put into the elements at key appended with their index, e.g.
+//[servers 0 host]. Elements that nothing is put into stay empty, and so the
+//list keeps its length (see v.Len()).
+//changed indicates whether or not this operation changes v in any way.
+//
+//Lists put with PutList are replaced as a whole when they are merged (see
+//v.Merge()). Loaders use PutList for the arrays they expand, so that a loaded
+//array replaces an earlier one, while an override of a single element, e.g. of
+//[servers 0 port] from an environment variable, does not.
+func (v *Values) PutList(key Key, length int) (changed bool) {
+	v.lock.Lock()
+	defer v.unlock()
+
+	m := v.mutation()
+	return m.writableRoot(v).putList(key, length, m)
+}
+
+//Source returns the description of where the value stored at key came from.
+//ok is false if no value is stored at key, if key references a subtree of values,
+//or if no source has been recorded for the value.
+//Sources are recorded by v.PutSource() and carried along by v.Merge() and
+//*Values values given to v.Put(), while values put without a source clear it.
+func (v *Values) Source(key Key) (source string, ok bool) {
+	if published := v.loadPublished(); published != nil {
+		return published.source(key)
+	}
+
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	return v.source(key)
+}
+
+func (v *Values) source(key Key) (string, bool) {
+	found := v.root.descendent(key)
+	if found == nil || !found.isSet() || found.source == "" {
+		return "", false
+	}
+	return found.source, true
 }
 
 //IsEmpty determines whether or not any associated exist in v.
@@ -91,27 +249,46 @@ func (v *Values) Get(key Key) (value interface{}) {
 
 //GetOk return the value associated with key.
 //The return value ok indicates whether or not any value is actually stored at key.
+//If key references a subtree, then value is a *Values snapshot (see v.Snapshot())
+//of that subtree, and so modifications to it DO NOT AFFECT v and vice versa.
 func (v *Values) GetOk(key Key) (value interface{}, ok bool) {
+	if published := v.loadPublished(); published != nil {
+		return published.getOk(key)
+	}
+
 	v.lock.RLock()
 	defer v.lock.RUnlock()
 
-	_, found, _ := v.root.findDescendent(nil, key, false, false)
+	return v.getOk(key)
+}
+
+func (v *Values) getOk(key Key) (interface{}, bool) {
+	found := v.root.descendent(key)
 	if found == nil {
 		return nil, false
 	}
 	if found.isSet() {
 		return found.value, true
 	}
-	return newValues(found.clone()), true
+	return v.subValues(found, key), true
 }
 
 //Clone creates a new Values with all associations copied into the result.
-//The individual values are shallow copied into the result.
+//The individual values are shallow copied into the result, while the tree
+//that holds them is copied entirely. Modifications to the result therefore
+//DO NOT AFFECT v and vice versa.
+//See v.Snapshot() for an O(1) alternative.
+//The result is read optimized if v is. See NewReadOptimizedValues().
 func (v *Values) Clone() *Values {
 	v.lock.RLock()
 	defer v.lock.RUnlock()
 
-	return newValues(v.root)
+	result := v.subValues(v.root.clone(), nil)
+	if v.published != nil {
+		result.published = &atomic.Value{}
+		result.publish()
+	}
+	return result
 }
 
 //Remove deletes the association stored at key if one exists.
@@ -119,8 +296,12 @@ func (v *Values) Clone() *Values {
 //was actually removed.
 func (v *Values) Remove(key Key) (value interface{}, ok bool) {
 	v.lock.Lock()
-	defer v.lock.Unlock()
+	defer v.unlock()
+
+	return v.remove(key)
+}
 
+func (v *Values) remove(key Key) (interface{}, bool) {
 	if key.IsEmpty() {
 		if v.root.isSet() {
 			result := v.root.value
@@ -130,7 +311,7 @@ func (v *Values) Remove(key Key) (value interface{}, ok bool) {
 		return nil, false
 	}
 
-	parent, found, _ := v.root.findDescendent(nil, key, false, false)
+	found := v.root.descendent(key)
 	if found == nil {
 		return nil, false
 	}
@@ -140,12 +321,46 @@ func (v *Values) Remove(key Key) (value interface{}, ok bool) {
 	} else {
 		result = newValues(found)
 	}
-	if parent != nil {
-		delete(parent.children, key[key.Len()-1])
-	}
+	parent := v.mutation().writableDescendent(v, key[:key.Len()-1])
+	delete(parent.children, key[key.Len()-1])
 	return result, true
 }
 
+//track calls mutate with v locked for writing and returns mutate's result along
+//with the Changes that mutate made to the subtree of v at key.
+//mutate must only use the unexported, non locking methods of v.
+func (v *Values) track(key Key, mutate func() bool) (bool, []Change) {
+	v.lock.Lock()
+	defer v.unlock()
+
+	key = v.root.affectedKey(key)
+	before := v.root.descendent(key)
+	if before != nil {
+		//mutate may modify the nodes of before in place.
+		before = before.clone()
+	}
+
+	changed := mutate()
+
+	changes := collectChanges(key, before, v.root.descendent(key), v.equalFunc())
+	v.sensitive.markChanges(changes)
+	return changed, changes
+}
+
+//replace replaces all associations in v with those in other and returns the
+//resulting Changes.
+//other's tree is taken over by v, so other must not be used by the caller afterwards.
+func (v *Values) replace(other *Values) []Change {
+	v.lock.Lock()
+	defer v.unlock()
+
+	before := v.root
+	v.root = other.root
+	changes := collectChanges(nil, before, v.root, v.equalFunc())
+	v.sensitive.markChanges(changes)
+	return changes
+}
+
 //node is the internal node type for a Values tree.
 //It stores the value stored at its location in the tree and links to children node.
 type node struct {
@@ -157,6 +372,19 @@ type node struct {
 	//children holds the references to this node's child nodes.
 	//The keys in children are the parts to the larger Key that references a value.
 	children map[string]*node
+
+	//source optionally describes where value came from.
+	//It is only meaningful for set nodes.
+	source string
+
+	//list marks n as a list put by Values.PutList(), which replaces whatever is
+	//stored at its Key as a whole when it is merged.
+	//It is only meaningful for nodes that are not set.
+	list bool
+
+	//generation is the generation of the Values that created or copied n.
+	//See mutation.
+	generation *generation
 }
 
 //newNodeValues creates a *node to value set to value and children set to nil.
@@ -170,8 +398,9 @@ func newNodeValue(value interface{}) *node {
 //newNode creates a *node with nil value and empty children.
 func newNode() *node {
 	return &node{
-		value:    nil,
-		children: map[string]*node{},
+		value:      nil,
+		children:   map[string]*node{},
+		generation: noGeneration,
 	}
 }
 
@@ -187,56 +416,142 @@ func (n *node) isSet() bool {
 	return n.children == nil
 }
 
-//put puts value at key within n's subtree or at n if key is empty.
-func (n *node) put(key Key, value interface{}) bool {
+//put puts value, with source, at key within n's subtree or at n if key is empty.
+//n must be writable by m, and all nodes on the way to key are copied as needed.
+//m.equal determines whether or not an existing value is changed.
+func (n *node) put(key Key, value interface{}, source string, m mutation) bool {
 	if key.IsEmpty() {
-		return n.setValue(value)
+		return n.setValue(value, source, m)
 	}
-	child, changed := n.findChild(key[0], true)
+	child, changed := n.writableChild(key[0], m)
 	remainingKey := key[1:]
-	return child.put(remainingKey, value) || changed
+	return child.put(remainingKey, value, source, m) || changed
 }
 
-//setValue sets n value to value.
-//if value is a *Values, then n.setValues(values.(*Values)) is used.
-func (n *node) setValue(value interface{}) bool {
+//setValue sets n value to value and n.source to source.
+//if value is a *Values, then n.setValues(values.(*Values), source, m) is used.
+func (n *node) setValue(value interface{}, source string, m mutation) bool {
 	if values, ok := value.(*Values); ok {
-		return n.setValues(values)
+		return n.setValues(values, source, m)
 	}
+	n.source = source
 	changed := false
 	if n.isSet() {
-		changed = value != n.value
+		changed = !m.equal(n.value, value)
 	} else {
 		changed = true
 	}
 	n.value = value
 	n.children = nil
+	n.list = false
 	return changed
 }
 
-//setValues calls n.put() for each key, value in values.
-func (n *node) setValues(values *Values) bool {
+//setValues merges values into n with n.merge().
+//Values without a source in values are put with source.
+func (n *node) setValues(values *Values, source string, m mutation) bool {
 	if values.IsEmpty() {
 		if n.isEmpty() {
 			return false
 		}
 		n.value = nil
 		n.children = nil
+		n.source = source
+		n.list = false
 		return true
 	}
+	values.lock.RLock()
+	defer values.lock.RUnlock()
+
+	return n.merge(nil, values.root, source, m)
+}
+
+//merge calls n.put() at key appended with each Key value association in other.
+//Values without a source in other are put with source.
+//A list in other (see Values.PutList()) replaces whatever is stored at its Key
+//as a whole with n.replace(), rather than being merged index by index.
+//n must be writable by m.
+func (n *node) merge(key Key, other *node, source string, m mutation) bool {
+	if other.isSet() {
+		if other.source != "" {
+			source = other.source
+		}
+		return n.put(key, other.value, source, m)
+	}
+	if other.list {
+		return n.replace(key, other, source, m)
+	}
 	changed := false
-	values.EachKeyValue(func(key Key, value interface{}) {
-		changed = n.put(key, value) || changed
-	})
+	for keyPart, child := range other.children {
+		changed = n.merge(key.AppendStrings(keyPart), child, source, m) || changed
+	}
 	return changed
 }
 
+//replace replaces whatever is stored at key in n with the associations in other.
+//Values without a source in other are put with source.
+//n must be writable by m.
+func (n *node) replace(key Key, other *node, source string, m mutation) bool {
+	target := n
+	for _, keyPart := range key {
+		target, _ = target.writableChild(keyPart, m)
+	}
+	before := target.copy()
+
+	target.value = nil
+	target.children = map[string]*node{}
+	target.source = ""
+	target.list = other.list
+	for keyPart, child := range other.children {
+		if child.isEmpty() {
+			target.children[keyPart] = m.newNode()
+			target.children[keyPart].list = child.list
+			continue
+		}
+		target.merge(NewKey(keyPart), child, source, m)
+	}
+	return !before.equal(target, m.equal)
+}
+
+//putList makes the subtree stored at key within n a list of length elements as
+//described by Values.PutList().
+//n must be writable by m.
+func (n *node) putList(key Key, length int, m mutation) bool {
+	target := n
+	for _, keyPart := range key {
+		target, _ = target.writableChild(keyPart, m)
+	}
+	before := target.copy()
+
+	if target.isSet() {
+		target.value = nil
+		target.children = map[string]*node{}
+		target.source = ""
+	}
+	for keyPart := range target.children {
+		if index, ok := listIndex(keyPart); !ok || index >= length {
+			delete(target.children, keyPart)
+		}
+	}
+	for i := 0; i < length; i++ {
+		keyPart := strconv.Itoa(i)
+		if _, ok := target.children[keyPart]; !ok {
+			target.children[keyPart] = m.newNode()
+		}
+	}
+	target.list = true
+	return !before.equal(target, m.equal) || !before.list
+}
+
 //clone returns a cloned n with a shallow copy of n.value and n.children cloned
 //via n.cloneChildren().
 func (n *node) clone() *node {
 	return &node{
-		value:    n.value,
-		children: n.cloneChildren(),
+		value:      n.value,
+		children:   n.cloneChildren(),
+		source:     n.source,
+		list:       n.list,
+		generation: n.generation,
 	}
 }
 
@@ -265,63 +580,116 @@ func (n *node) eachKeyValue(key Key, visitor func(key Key, value interface{})) {
 	}
 }
 
-//equal determines if n and other are equal by n.value == other.value and n.childrenEqual(other)
-func (n *node) equal(other *node) bool {
-	return n.value == other.value && n.childrenEqual(other)
+//eachKeyValueSource is n.eachKeyValue() that also visits each value's source.
+func (n *node) eachKeyValueSource(key Key, visitor func(key Key, value interface{}, source string)) {
+	if n.isSet() {
+		visitor(key, n.value, n.source)
+		return
+	}
+	for keyPart, childNode := range n.children {
+		childNode.eachKeyValueSource(append(NewKey(key...), keyPart), visitor)
+	}
+}
+
+//equal determines if n and other are equal by equal(n.value, other.value) if
+//both are set, and by n.childrenEqual(other, equal) if neither is.
+//equal is only called with values of set nodes.
+func (n *node) equal(other *node, equal EqualFunc) bool {
+	if n.isSet() || other.isSet() {
+		return n.isSet() && other.isSet() && equal(n.value, other.value)
+	}
+	return n.childrenEqual(other, equal)
 }
 
 //childrenEqual determines if n and other's children have the same set of keys
 //and all associated child nodes are equal via *node.equal().
-func (n *node) childrenEqual(other *node) bool {
+func (n *node) childrenEqual(other *node, equal EqualFunc) bool {
 	if len(n.children) != len(other.children) {
 		return false
 	}
 	for keyPart, child := range n.children {
 		otherChild, ok := other.children[keyPart]
-		if !ok || !child.equal(otherChild) {
+		if !ok || !child.equal(otherChild, equal) {
 			return false
 		}
 	}
 	return true
 }
 
-//findDescendent finds a desired descendent (node at any lower level) and its possible
-//parent.
-//The parameter parent should be n's parent. This must be ensured by the caller.
-func (n *node) findDescendent(parent *node, key Key, canChange, hasChanged bool) (foundParent *node, found *node, changed bool) {
-	//if there is no more key to look into, then return parent, n, and hasChanged.
-	if key.IsEmpty() {
-		return parent, n, hasChanged
+//matchChild returns the child of n keyed by name along with its actual key part.
+//An exact match is preferred, otherwise a case insensitive match is returned.
+//nil is returned if no child matches.
+func (n *node) matchChild(name string) (string, *node) {
+	if child, ok := n.children[name]; ok {
+		return name, child
+	}
+	match := ""
+	for keyPart := range n.children {
+		if strings.EqualFold(keyPart, name) && (match == "" || keyPart < match) {
+			match = keyPart
+		}
 	}
-	//find the child at the next part in key, possibly creating or changing with canChange.
-	child, changed := n.findChild(key[0], canChange)
-	if child == nil {
-		return parent, nil, changed
+	if match == "" {
+		return "", nil
 	}
-	//recurse into children.
-	return child.findDescendent(n, key[1:], canChange, changed || hasChanged)
+	return match, n.children[match]
 }
 
-//findChild find a child node of n at keyPart.
-//canChange tells findChild if it can modify n.children to modify n.value and or n.children.
-//Essentially, canChange being true performs a put, and false performs a get or lookup.
-func (n *node) findChild(keyPart string, canChange bool) (*node, bool) {
-	changed := false
+//interfaceValue returns n.value if n is set, a []interface{} of each child's
+//interfaceValue() if n is a list, and a map[string]interface{} with each child's
+//interfaceValue() otherwise.
+func (n *node) interfaceValue() interface{} {
 	if n.isSet() {
-		if !canChange {
-			return nil, false
+		return n.value
+	}
+	if length, ok := n.listLen(); ok {
+		result := make([]interface{}, length)
+		for i := range result {
+			result[i] = n.children[strconv.Itoa(i)].interfaceValue()
 		}
-		n.value, changed = nil, true
-		n.children = map[string]*node{}
+		return result
+	}
+	result := make(map[string]interface{}, len(n.children))
+	for keyPart, child := range n.children {
+		result[keyPart] = child.interfaceValue()
 	}
-	child, ok := n.children[keyPart]
-	if !ok {
-		if !canChange {
-			return nil, false
+	return result
+}
+
+//descendent returns the node stored at key within n's subtree or nil if one
+//does not exist.
+func (n *node) descendent(key Key) *node {
+	found := n
+	for _, keyPart := range key {
+		if found = found.findChild(keyPart); found == nil {
+			return nil
 		}
-		n.children[keyPart] = newNode()
-		child = n.children[keyPart]
-		changed = true
 	}
-	return child, changed
+	return found
+}
+
+//affectedKey returns the shortest prefix of key whose subtree contains all
+//changes a put at key can make within n.
+//This is key itself unless a set node is stored at one of key's prefixes.
+func (n *node) affectedKey(key Key) Key {
+	current := n
+	for i, keyPart := range key {
+		if current.isSet() {
+			return NewKey(key[:i]...)
+		}
+		child, ok := current.children[keyPart]
+		if !ok {
+			break
+		}
+		current = child
+	}
+	return NewKey(key...)
+}
+
+//findChild returns the child node of n at keyPart, or nil if there is none.
+func (n *node) findChild(keyPart string) *node {
+	if n.isSet() {
+		return nil
+	}
+	return n.children[keyPart]
 }
@@ -3,7 +3,9 @@ package config
 import (
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type KeyValue struct {
@@ -393,6 +395,221 @@ func TestValues_Merge(t *testing.T) {
 	}
 }
 
+func TestValues_Merge_lists(t *testing.T) {
+	v := NewValues()
+	v.PutList(NewKey("a"), 3)
+	v.Put(NewKey("a", "0", "b"), "b")
+	v.Put(NewKey("a", "0", "c"), "c")
+	v.Put(NewKey("a", "1"), "1")
+	v.Put(NewKey("a", "2"), "2")
+	v.PutList(NewKey("d"), 1)
+	v.Put(NewKey("d", "0"), "0")
+	v.Put(NewKey("e"), "e")
+
+	other := NewValues()
+	other.PutList(NewKey("a"), 1)
+	other.Put(NewKey("a", "0", "b"), "x")
+	other.PutList(NewKey("d"), 1)
+	other.Put(NewKey("d", "0"), "0")
+	other.PutList(NewKey("e"), 1)
+	other.Put(NewKey("e", "0"), "0")
+
+	if changed := v.Merge(nil, other); !changed {
+		t.Error("v.Merge() should change v")
+	}
+	want := NewValues()
+	want.Put(NewKey("a", "0", "b"), "x")
+	want.Put(NewKey("d", "0"), "0")
+	want.Put(NewKey("e", "0"), "0")
+	if !v.Equal(want) {
+		t.Errorf("v = %v WANT %v", v, want)
+	}
+	if length := v.Len(NewKey("a")); length != 1 {
+		t.Errorf("v.Len(a) = %v WANT 1", length)
+	}
+
+	same := NewValues()
+	same.PutList(NewKey("d"), 1)
+	same.Put(NewKey("d", "0"), "0")
+	if changed := v.Merge(nil, same); changed {
+		t.Error("v.Merge() of an equal list should not change v")
+	}
+	empty := NewValues()
+	empty.Put(NewKey("a"), []interface{}{})
+	if changed := v.Merge(nil, empty); !changed || v.Len(NewKey("a")) != 0 {
+		t.Errorf("v.Merge() of an empty list = %v, v.Len(a) = %v", changed, v.Len(NewKey("a")))
+	}
+}
+
+func TestValues_Merge_listElements(t *testing.T) {
+	newServers := func() *Values {
+		v := NewValues()
+		v.PutList(NewKey("servers"), 2)
+		v.Put(NewKey("servers", "0", "host"), "a")
+		v.Put(NewKey("servers", "0", "port"), 1)
+		v.Put(NewKey("servers", "1", "host"), "b")
+		v.Put(NewKey("servers", "1", "port"), 2)
+		return v
+	}
+
+	for _, index := range []string{"0", "1"} {
+		v := newServers()
+		override := NewValues()
+		override.Put(NewKey("servers", index, "port"), 9)
+		if changed := v.Merge(nil, override); !changed {
+			t.Errorf("%v: v.Merge() should change v", index)
+		}
+
+		want := newServers()
+		want.Put(NewKey("servers", index, "port"), 9)
+		if !v.Equal(want) || v.Len(NewKey("servers")) != 2 {
+			t.Errorf("%v: v = %v WANT %v", index, v, want)
+		}
+	}
+
+	v := newServers()
+	list := NewValues()
+	list.PutList(nil, 1)
+	list.Put(NewKey("0", "port"), 9)
+	v.Put(NewKey("servers"), list)
+	want := NewValues()
+	want.Put(NewKey("servers", "0", "port"), 9)
+	if !v.Equal(want) {
+		t.Errorf("v.Put(servers, list) = %v WANT %v", v, want)
+	}
+}
+
+func TestValues_MergeSource(t *testing.T) {
+	v := NewValues()
+	v.PutSource(NewKey("a"), "a", "v")
+
+	other := NewValues()
+	other.PutSource(NewKey("b"), "b", "other")
+	other.Put(NewKey("c"), "c")
+	if changed := v.MergeSource(nil, other, "merged"); !changed {
+		t.Error("v.MergeSource() should change v")
+	}
+
+	for key, want := range map[string]string{"a": "v", "b": "other", "c": "merged"} {
+		if source, _ := v.Source(NewKey(key)); source != want {
+			t.Errorf("v.Source(%v) = %q WANT %q", key, source, want)
+		}
+	}
+}
+
+func TestValues_PutList(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("a"), "a")
+	if changed := v.PutList(NewKey("a"), 2); !changed {
+		t.Error("v.PutList() should change v")
+	}
+	if length := v.Len(NewKey("a")); length != 2 {
+		t.Errorf("v.Len(a) = %v WANT 2", length)
+	}
+	if s := v.GetSlice(NewKey("a")); len(s) != 2 || !s[0].(*Values).IsEmpty() {
+		t.Errorf("v.GetSlice(a) = %v WANT 2 empty elements", s)
+	}
+	if changed := v.PutList(NewKey("a"), 2); changed {
+		t.Error("v.PutList() of the same list should not change v")
+	}
+
+	v.Put(NewKey("a", "1", "b"), "b")
+	v.Put(NewKey("a", "x"), "x")
+	v.Put(NewKey("a", "01"), "01")
+	v.PutList(NewKey("a"), 2)
+	want := NewValues()
+	want.Put(NewKey("a", "1", "b"), "b")
+	want.PutList(NewKey("a"), 2)
+	if !v.Equal(want) {
+		t.Errorf("v = %v WANT %v", v, want)
+	}
+
+	var out struct {
+		A []struct{ B string }
+	}
+	if err := v.Decode(&out); err != nil || len(out.A) != 2 || out.A[1].B != "b" {
+		t.Errorf("v.Decode() = %+v, %v", out, err)
+	}
+
+	v.PutList(NewKey("a"), 0)
+	if s, ok := v.GetSliceOk(NewKey("a")); !ok || len(s) != 0 {
+		t.Errorf("v.GetSliceOk(a) = %v, %v WANT [], true", s, ok)
+	}
+}
+
+func TestValues_Merge_self(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("a"), "a")
+
+	withoutDeadlock(t, func() {
+		v.Merge(NewKey("b"), v)
+		v.Put(NewKey("c"), v)
+	})
+
+	want := NewValues()
+	want.Put(NewKey("a"), "a")
+	want.Put(NewKey("b", "a"), "a")
+	want.Put(NewKey("c", "a"), "a")
+	want.Put(NewKey("c", "b", "a"), "a")
+	if !v.Equal(want) || !v.Equal(v) || len(v.Diff(v)) != 0 {
+		t.Errorf("v = %v WANT %v", v, want)
+	}
+}
+
+func TestValues_Merge_crossed(t *testing.T) {
+	a, b := NewValues(), NewValues()
+	a.Put(NewKey("a"), "a")
+	b.Put(NewKey("b"), "b")
+
+	withoutDeadlock(t, func() {
+		wg := &sync.WaitGroup{}
+		for i := 0; i < 100; i++ {
+			wg.Add(6)
+			go func() {
+				defer wg.Done()
+				a.Merge(nil, b)
+			}()
+			go func() {
+				defer wg.Done()
+				b.Merge(nil, a)
+			}()
+			go func(i int) {
+				defer wg.Done()
+				a.Put(NewKey("a"), i)
+			}(i)
+			go func() {
+				defer wg.Done()
+				b.Equal(a)
+			}()
+			go func() {
+				defer wg.Done()
+				a.Diff(b)
+			}()
+			go func() {
+				defer wg.Done()
+				b.Diff(a)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+//withoutDeadlock fails t if f does not return within a generous timeout.
+func withoutDeadlock(t *testing.T, f func()) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("deadlock")
+	}
+}
+
 func TestValues_EachKeyValue(t *testing.T) {
 	tests := []struct {
 		values *Values
@@ -1016,6 +1233,8 @@ func TestValues_Put(t *testing.T) {
 		if changed != test.changed {
 			t.Errorf("%v, v.Put(%v) changed = %v WANT %v", index, test.key, changed, test.changed)
 		}
+		clearGenerations(v.root)
+		clearGenerations(test.root)
 		if !reflect.DeepEqual(v.root, test.root) {
 			t.Errorf("%v, v.Put(%v) root = %v WANT %v", index, test.key, v.root, test.root)
 		}
@@ -1032,6 +1251,47 @@ func areChildrenEqual(a, b map[string]*node) bool {
 	return reflect.DeepEqual(a, b)
 }
 
+func TestValues_Source(t *testing.T) {
+	v := NewValues()
+	v.PutSource(NewKey("a", "b"), "b", "first")
+	v.PutSource(NewKey("a", "c"), "c", "first")
+	v.Put(NewKey("d"), "d")
+
+	sourced := NewValues()
+	sourced.PutSource(NewKey("e"), "e", "sourced")
+	sourced.Put(NewKey("f"), "f")
+	v.PutSource(NewKey("g"), sourced, "default")
+
+	other := NewValues()
+	other.PutSource(NewKey("a", "c"), "c", "second")
+	v.Merge(nil, other)
+
+	tests := []struct {
+		key    Key
+		source string
+		ok     bool
+	}{
+		{NewKey("a", "b"), "first", true},
+		{NewKey("a", "c"), "second", true},
+		{NewKey("a"), "", false},
+		{NewKey("d"), "", false},
+		{NewKey("g", "e"), "sourced", true},
+		{NewKey("g", "f"), "default", true},
+		{NewKey("missing"), "", false},
+	}
+	for _, test := range tests {
+		source, ok := v.Source(test.key)
+		if source != test.source || ok != test.ok {
+			t.Errorf("v.Source(%v) = %v, %v WANT %v, %v", test.key, source, ok, test.source, test.ok)
+		}
+	}
+
+	v.Put(NewKey("a", "b"), "b")
+	if source, ok := v.Source(NewKey("a", "b")); source != "" || ok {
+		t.Errorf("v.Put() should clear the source, got %v, %v", source, ok)
+	}
+}
+
 func TestValues_IsEmpty(t *testing.T) {
 	tests := []struct {
 		values  *Values
@@ -1282,6 +1542,98 @@ func TestValues_Equal(t *testing.T) {
 	}
 }
 
+func TestValues_Diff(t *testing.T) {
+	newValues := func(kvs ...KeyValue) *Values {
+		v := NewValues()
+		for _, kv := range kvs {
+			v.Put(kv.Key, kv.Value)
+		}
+		return v
+	}
+	tests := []struct {
+		a       *Values
+		b       *Values
+		changes []Change
+	}{
+		{NewValues(), NewValues(), nil},
+		{
+			NewValues(),
+			newValues(NewKeyValue(NewKey("a"), "a")),
+			[]Change{
+				{Kind: ChangeAdded, Key: NewKey("a"), New: "a"},
+			},
+		},
+		{
+			newValues(NewKeyValue(NewKey("a"), "a")),
+			NewValues(),
+			[]Change{
+				{Kind: ChangeRemoved, Key: NewKey("a"), Old: "a"},
+			},
+		},
+		{
+			newValues(
+				NewKeyValue(NewKey("a", "b"), "b"),
+				NewKeyValue(NewKey("a", "c"), "c"),
+				NewKeyValue(NewKey("d"), "d"),
+			),
+			newValues(
+				NewKeyValue(NewKey("a", "b"), "b"),
+				NewKeyValue(NewKey("a", "c"), 3),
+				NewKeyValue(NewKey("e"), "e"),
+			),
+			[]Change{
+				{Kind: ChangeModified, Key: NewKey("a", "c"), Old: "c", New: 3},
+				{Kind: ChangeRemoved, Key: NewKey("d"), Old: "d"},
+				{Kind: ChangeAdded, Key: NewKey("e"), New: "e"},
+			},
+		},
+		//leaf replaced by subtree
+		{
+			newValues(NewKeyValue(NewKey("a"), "a")),
+			newValues(
+				NewKeyValue(NewKey("a", "c"), "c"),
+				NewKeyValue(NewKey("a", "b"), "b"),
+			),
+			[]Change{
+				{Kind: ChangeRemoved, Key: NewKey("a"), Old: "a"},
+				{Kind: ChangeAdded, Key: NewKey("a", "b"), New: "b"},
+				{Kind: ChangeAdded, Key: NewKey("a", "c"), New: "c"},
+			},
+		},
+		//subtree replaced by leaf
+		{
+			newValues(NewKeyValue(NewKey("a", "b"), "b")),
+			newValues(NewKeyValue(NewKey("a"), "a")),
+			[]Change{
+				{Kind: ChangeRemoved, Key: NewKey("a", "b"), Old: "b"},
+				{Kind: ChangeAdded, Key: NewKey("a"), New: "a"},
+			},
+		},
+		//value at root
+		{
+			newValues(NewKeyValue(nil, 1)),
+			newValues(NewKeyValue(nil, 2)),
+			[]Change{
+				{Kind: ChangeModified, Key: NewKey(), Old: 1, New: 2},
+			},
+		},
+	}
+	for index, test := range tests {
+		changes := test.a.Diff(test.b)
+		if !reflect.DeepEqual(changes, test.changes) {
+			t.Errorf("%v, test.a.Diff(test.b) = %v WANT %v", index, changes, test.changes)
+		}
+	}
+}
+
+func TestValues_Diff_self(t *testing.T) {
+	v := NewValues()
+	v.Put(NewKey("a"), "a")
+	if changes := v.Diff(v); changes != nil {
+		t.Errorf("v.Diff(v) = %v WANT nil", changes)
+	}
+}
+
 func TestValues_Remove(t *testing.T) {
 	tests := []struct {
 		values  *Values
@@ -1419,14 +1771,22 @@ func TestNewNode(t *testing.T) {
 	}
 }
 
-func TestNode_findChild_isSetAndCannotChange(t *testing.T) {
+func TestNode_findChild_isSet(t *testing.T) {
 	n := newNodeValue(0)
-	child, changed := n.findChild("", false)
-	if child != nil || changed {
+	if child := n.findChild(""); child != nil {
 		t.Fail()
 	}
 }
 
+//clearGenerations clears the generation of each node in n's subtree so that it
+//may be compared with node literals.
+func clearGenerations(n *node) {
+	n.generation = nil
+	for _, child := range n.children {
+		clearGenerations(child)
+	}
+}
+
 func testNode(t *testing.T, n *node, value interface{}, childrenNil bool) {
 	if n == nil {
 		t.Error("*node should not be nil")
@@ -0,0 +1,81 @@
+package config
+
+//watcher is a single subscription registered with Config.WatchKey.
+type watcher struct {
+	prefix Key
+	fn     func(Change)
+}
+
+//Watch is sugar for c.WatchKey(c.NewKey(prefix), fn) except that an empty prefix
+//is treated as an empty Key, which watches every Key in c.
+func (c *Config) Watch(prefix string, fn func(Change)) (cancel func()) {
+	if prefix == "" {
+		return c.WatchKey(nil, fn)
+	}
+	return c.WatchKey(c.NewKey(prefix), fn)
+}
+
+//WatchKey registers fn to be called with every Change made to a Key that starts
+//with prefix. A prefix equal to the full Key of a value therefore watches that
+//single value, and an empty prefix watches all of c.
+//
+//Changes are detected for modifications made through c's Put, PutKey, Remove,
+//Merge, MergeLoaders, LoadAll, and Reload methods.
+//Modifications made directly to c.Values() are not detected.
+//fn is called synchronously by the goroutine making the modification after
+//the modification is complete, so fn may safely call methods on c.
+//
+//The returned cancel func unregisters fn. It is safe to call more than once.
+func (c *Config) WatchKey(prefix Key, fn func(Change)) (cancel func()) {
+	w := &watcher{
+		prefix: NewKey(prefix...),
+		fn:     fn,
+	}
+
+	c.watchLock.Lock()
+	defer c.watchLock.Unlock()
+
+	c.watchers = append(c.watchers, w)
+	return func() {
+		c.unwatch(w)
+	}
+}
+
+func (c *Config) unwatch(w *watcher) {
+	c.watchLock.Lock()
+	defer c.watchLock.Unlock()
+
+	for i, other := range c.watchers {
+		if other == w {
+			c.watchers = append(c.watchers[:i:i], c.watchers[i+1:]...)
+			return
+		}
+	}
+}
+
+//isWatched determines whether or not any watchers are registered with c.
+func (c *Config) isWatched() bool {
+	c.watchLock.RLock()
+	defer c.watchLock.RUnlock()
+
+	return len(c.watchers) > 0
+}
+
+//notify calls every registered watcher whose prefix matches each Change in changes.
+func (c *Config) notify(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	c.watchLock.RLock()
+	watchers := c.watchers
+	c.watchLock.RUnlock()
+
+	for _, change := range changes {
+		for _, w := range watchers {
+			if change.Key.StartsWith(w.prefix) {
+				w.fn(change)
+			}
+		}
+	}
+}
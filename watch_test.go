@@ -0,0 +1,114 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfig_Watch(t *testing.T) {
+	c := New()
+	c.Put("db.host", "localhost")
+	c.Put("cache.size", 10)
+
+	var all, db, host []Change
+	c.Watch("", func(change Change) { all = append(all, change) })
+	c.Watch("db", func(change Change) { db = append(db, change) })
+	c.Watch("db.host", func(change Change) { host = append(host, change) })
+
+	c.Put("db.host", "remote")
+	c.Put("db.host", "remote")
+	c.Put("db.port", 5432)
+	c.Put("cache.size", 20)
+	c.Remove("db")
+
+	wantAll := []Change{
+		{Kind: ChangeModified, Key: NewKey("db", "host"), Old: "localhost", New: "remote"},
+		{Kind: ChangeAdded, Key: NewKey("db", "port"), New: 5432},
+		{Kind: ChangeModified, Key: NewKey("cache", "size"), Old: 10, New: 20},
+		{Kind: ChangeRemoved, Key: NewKey("db", "host"), Old: "remote"},
+		{Kind: ChangeRemoved, Key: NewKey("db", "port"), Old: 5432},
+	}
+	wantDB := []Change{wantAll[0], wantAll[1], wantAll[3], wantAll[4]}
+	wantHost := []Change{wantAll[0], wantAll[3]}
+
+	if !reflect.DeepEqual(all, wantAll) {
+		t.Errorf("all = %v WANT %v", all, wantAll)
+	}
+	if !reflect.DeepEqual(db, wantDB) {
+		t.Errorf("db = %v WANT %v", db, wantDB)
+	}
+	if !reflect.DeepEqual(host, wantHost) {
+		t.Errorf("host = %v WANT %v", host, wantHost)
+	}
+}
+
+func TestConfig_Watch_leafReplacedBySubtree(t *testing.T) {
+	c := New()
+	c.Put("a", 1)
+
+	var changes []Change
+	c.Watch("a", func(change Change) { changes = append(changes, change) })
+
+	c.Put("a.b", 2)
+
+	want := []Change{
+		{Kind: ChangeRemoved, Key: NewKey("a"), Old: 1},
+		{Kind: ChangeAdded, Key: NewKey("a", "b"), New: 2},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %v WANT %v", changes, want)
+	}
+}
+
+func TestConfig_Watch_merge(t *testing.T) {
+	c := New()
+	c.Put("a", 1)
+
+	var changes []Change
+	c.Watch("", func(change Change) { changes = append(changes, change) })
+
+	other := New()
+	other.Put("a", 2)
+	other.Put("b", 3)
+	c.Merge(other)
+
+	c.MergeLoaders(intLoader(4))
+
+	want := []Change{
+		{Kind: ChangeModified, Key: NewKey("a"), Old: 1, New: 2},
+		{Kind: ChangeAdded, Key: NewKey("b"), New: 3},
+		{Kind: ChangeAdded, Key: NewKey("4"), New: 4},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %v WANT %v", changes, want)
+	}
+}
+
+func TestConfig_Watch_cancel(t *testing.T) {
+	c := New()
+
+	calls := 0
+	cancel := c.Watch("a", func(Change) { calls++ })
+
+	c.Put("a", 1)
+	cancel()
+	cancel()
+	c.Put("a", 2)
+
+	if calls != 1 || c.isWatched() {
+		t.Errorf("calls = %v, c.isWatched() = %v", calls, c.isWatched())
+	}
+}
+
+func TestConfig_Watch_reentrant(t *testing.T) {
+	c := New()
+	c.Watch("a", func(change Change) {
+		c.Put("b", change.New)
+	})
+
+	c.Put("a", 1)
+
+	if c.Get("b") != 1 {
+		t.Errorf("c.Get(b) = %v WANT 1", c.Get("b"))
+	}
+}